@@ -0,0 +1,169 @@
+package maxcdn
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// AnomalyMethod selects how AnomalyDetector builds its baseline.
+type AnomalyMethod int
+
+const (
+
+	// MeanStddev compares points to the rolling mean, in standard
+	// deviations.
+	MeanStddev AnomalyMethod = iota
+
+	// MedianMAD compares points to the rolling median, in median absolute
+	// deviations scaled to match the standard deviation of normal data.
+	// It's less affected by earlier anomalies in the window.
+	MedianMAD
+)
+
+// AnomalyKind tells spikes from drops.
+type AnomalyKind string
+
+// Anomaly kinds.
+const (
+	AnomalySpike AnomalyKind = "spike"
+	AnomalyDrop  AnomalyKind = "drop"
+)
+
+// Anomaly is a point of a series which strays from the points before it.
+type Anomaly struct {
+	Timestamp time.Time   `json:"timestamp"`
+	Metric    StatsMetric `json:"metric"`
+	Kind      AnomalyKind `json:"kind"`
+	Value     int64       `json:"value"`
+
+	// Expected is the baseline mean or median, and Deviation the baseline
+	// spread the Score is measured in.
+	Expected  float64 `json:"expected"`
+	Deviation float64 `json:"deviation"`
+	Score     float64 `json:"score"`
+}
+
+// Default AnomalyDetector settings.
+const (
+	DefaultAnomalyWindow      = 24
+	DefaultAnomalySensitivity = 3
+)
+
+// DefaultAnomalyMetrics are checked when AnomalyDetector.Metrics is empty.
+var DefaultAnomalyMetrics = []StatsMetric{MetricHits, MetricBytes, MetricNonCacheHits}
+
+// AnomalyDetector flags spikes and drops in a stats series by comparing every
+// point to a rolling baseline of the points before it.
+type AnomalyDetector struct {
+	Method AnomalyMethod
+
+	// Window is the number of points in the baseline, DefaultAnomalyWindow
+	// when zero. Points with fewer than three points before them aren't
+	// checked.
+	Window int
+
+	// Sensitivity is the score a point must reach to be flagged,
+	// DefaultAnomalySensitivity when zero. Lower is more sensitive.
+	Sensitivity float64
+
+	Metrics []StatsMetric
+}
+
+// Detect returns the anomalies in series, ordered by time then metric.
+func (d AnomalyDetector) Detect(series StatsSeries) []Anomaly {
+	window := d.Window
+	if window <= 0 {
+		window = DefaultAnomalyWindow
+	}
+	sensitivity := d.Sensitivity
+	if sensitivity <= 0 {
+		sensitivity = DefaultAnomalySensitivity
+	}
+	metrics := d.Metrics
+	if len(metrics) == 0 {
+		metrics = DefaultAnomalyMetrics
+	}
+
+	sorted := append(StatsSeries(nil), series...)
+	sorted.sort()
+
+	var (
+		anomalies []Anomaly
+		baseline  = make([]float64, 0, window)
+	)
+	for i := 3; i < len(sorted); i++ {
+		from := i - window
+		if from < 0 {
+			from = 0
+		}
+
+		for _, m := range metrics {
+			baseline = baseline[:0]
+			for _, p := range sorted[from:i] {
+				baseline = append(baseline, float64(p.Value(m)))
+			}
+
+			expected, deviation := d.baseline(baseline)
+			if deviation == 0 {
+				// A flat baseline flags any change of more than
+				// Sensitivity percent.
+				deviation = math.Max(math.Abs(expected)/100, 1)
+			}
+
+			value := sorted[i].Value(m)
+			score := (float64(value) - expected) / deviation
+			if math.Abs(score) < sensitivity {
+				continue
+			}
+
+			a := Anomaly{
+				Timestamp: sorted[i].Timestamp,
+				Metric:    m,
+				Kind:      AnomalySpike,
+				Value:     value,
+				Expected:  expected,
+				Deviation: deviation,
+				Score:     score,
+			}
+			if score < 0 {
+				a.Kind = AnomalyDrop
+			}
+			anomalies = append(anomalies, a)
+		}
+	}
+	return anomalies
+}
+
+// baseline returns the center and spread of values, which it may reorder.
+func (d AnomalyDetector) baseline(values []float64) (float64, float64) {
+	if d.Method == MedianMAD {
+		center := median(values)
+		deviations := make([]float64, len(values))
+		for i, v := range values {
+			deviations[i] = math.Abs(v - center)
+		}
+		return center, median(deviations) * 1.4826
+	}
+
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+
+	var sq float64
+	for _, v := range values {
+		sq += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(sq / float64(len(values)))
+}
+
+func median(values []float64) float64 {
+	sort.Float64s(values)
+	n := len(values)
+	if n%2 == 1 {
+		return values[n/2]
+	}
+	return (values[n/2-1] + values[n/2]) / 2
+}
@@ -0,0 +1,76 @@
+package maxcdn
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func hourlySeries(hits ...int64) StatsSeries {
+	start := time.Date(2014, 7, 1, 0, 0, 0, 0, time.UTC)
+	series := make(StatsSeries, len(hits))
+	for i, h := range hits {
+		series[i] = StatsPoint{
+			Timestamp:    start.Add(time.Duration(i) * time.Hour),
+			StatsSummary: StatsSummary{Hits: h, Bytes: h * 1000},
+		}
+	}
+	return series
+}
+
+func TestAnomalyDetector_meanStddev(t *testing.T) {
+	assert := assert.New(t)
+
+	series := hourlySeries(100, 104, 96, 101, 99, 103, 97, 300, 100, 98)
+	anomalies := AnomalyDetector{Window: 6, Metrics: []StatsMetric{MetricHits}}.Detect(series)
+
+	assert.Len(anomalies, 1)
+
+	spike := anomalies[0]
+	assert.Equal(series[7].Timestamp, spike.Timestamp)
+	assert.Equal(MetricHits, spike.Metric)
+	assert.Equal(AnomalySpike, spike.Kind)
+	assert.Equal(int64(300), spike.Value)
+	assert.InDelta(100.0, spike.Expected, 1e-9)
+	assert.True(spike.Score > 3)
+}
+
+func TestAnomalyDetector_medianMAD(t *testing.T) {
+	assert := assert.New(t)
+
+	// The spike inflates the stddev of the following windows, hiding the
+	// drop from MeanStddev.
+	series := hourlySeries(100, 104, 96, 101, 99, 103, 97, 300, 100, 98, 5)
+
+	stddev := AnomalyDetector{Window: 6, Metrics: []StatsMetric{MetricHits}}.Detect(series)
+	assert.Len(stddev, 1)
+
+	mad := AnomalyDetector{Method: MedianMAD, Window: 6, Metrics: []StatsMetric{MetricHits}}.Detect(series)
+	assert.Len(mad, 2)
+	assert.Equal(AnomalySpike, mad[0].Kind)
+	assert.Equal(AnomalyDrop, mad[1].Kind)
+	assert.Equal(int64(5), mad[1].Value)
+	assert.InDelta(99.5, mad[1].Expected, 1e-9)
+}
+
+func TestAnomalyDetector_defaults(t *testing.T) {
+	assert := assert.New(t)
+
+	// flat baseline, unsorted input
+	series := hourlySeries(100, 100, 100, 100, 102, 110)
+	series[0], series[5] = series[5], series[0]
+
+	anomalies := AnomalyDetector{}.Detect(series)
+	// noncache_hits stays at zero
+	assert.Len(anomalies, 2)
+	for _, a := range anomalies {
+		assert.Equal(series[0].Timestamp, a.Timestamp)
+		assert.Equal(AnomalySpike, a.Kind)
+	}
+	assert.Equal(MetricHits, anomalies[0].Metric)
+	assert.Equal(MetricBytes, anomalies[1].Metric)
+
+	assert.Empty(AnomalyDetector{}.Detect(series[:3]))
+	assert.Empty(AnomalyDetector{Sensitivity: 20}.Detect(series))
+}
@@ -0,0 +1,235 @@
+package maxcdn
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+// Default anonymization settings.
+const (
+	DefaultIPv4Prefix     = 24
+	DefaultIPv6Prefix     = 48
+	DefaultCoordinateGrid = 0.1
+)
+
+// AnonymizerOptions configures an Anonymizer.
+type AnonymizerOptions struct {
+
+	// IPv4Prefix and IPv6Prefix are the number of leading bits kept of
+	// client IPs, DefaultIPv4Prefix and DefaultIPv6Prefix when zero.
+	IPv4Prefix int
+	IPv6Prefix int
+
+	// CoordinateGrid is the size, in degrees, of the grid client latitudes
+	// and longitudes are rounded to, DefaultCoordinateGrid when zero.
+	CoordinateGrid float64
+
+	// DropCity clears the client city and DMA.
+	DropCity bool
+
+	// DenyParams are the query string parameters, matched case
+	// insensitively, removed from query strings, URIs and referers.
+	DenyParams []string
+
+	// HashFields are the string fields, by JSON name such as "client_ip",
+	// replaced by their HMAC-SHA256 under HashKey. Fields are hashed after
+	// being truncated or stripped, so hashes can't be reversed by trying
+	// every address, while equal values still hash equally.
+	HashFields []string
+	HashKey    []byte
+}
+
+// Anonymizer strips and pseudonymizes the personal data of log records.
+type Anonymizer struct {
+	ipv4Mask net.IPMask
+	ipv6Mask net.IPMask
+	grid     float64
+	dropCity bool
+	deny     []string
+	hash     map[string]int
+	key      []byte
+}
+
+// NewAnonymizer validates opts and returns an Anonymizer.
+func NewAnonymizer(opts AnonymizerOptions) (*Anonymizer, error) {
+	if opts.IPv4Prefix == 0 {
+		opts.IPv4Prefix = DefaultIPv4Prefix
+	}
+	if opts.IPv6Prefix == 0 {
+		opts.IPv6Prefix = DefaultIPv6Prefix
+	}
+	if opts.CoordinateGrid == 0 {
+		opts.CoordinateGrid = DefaultCoordinateGrid
+	}
+
+	if opts.IPv4Prefix < 0 || opts.IPv4Prefix > 32 {
+		return nil, fmt.Errorf("maxcdn: invalid IPv4 prefix %d", opts.IPv4Prefix)
+	}
+	if opts.IPv6Prefix < 0 || opts.IPv6Prefix > 128 {
+		return nil, fmt.Errorf("maxcdn: invalid IPv6 prefix %d", opts.IPv6Prefix)
+	}
+	if opts.CoordinateGrid < 0 || math.IsInf(opts.CoordinateGrid, 0) || math.IsNaN(opts.CoordinateGrid) {
+		return nil, fmt.Errorf("maxcdn: invalid coordinate grid %v", opts.CoordinateGrid)
+	}
+	if len(opts.HashFields) > 0 && len(opts.HashKey) == 0 {
+		return nil, fmt.Errorf("maxcdn: hashing log fields requires a key")
+	}
+
+	a := &Anonymizer{
+		ipv4Mask: net.CIDRMask(opts.IPv4Prefix, 32),
+		ipv6Mask: net.CIDRMask(opts.IPv6Prefix, 128),
+		grid:     opts.CoordinateGrid,
+		dropCity: opts.DropCity,
+		deny:     opts.DenyParams,
+		hash:     map[string]int{},
+		key:      opts.HashKey,
+	}
+
+	t := reflect.TypeOf(LogRecord{})
+	for _, name := range opts.HashFields {
+		i, ok := logFieldIndex[name]
+		if !ok {
+			return nil, fmt.Errorf("maxcdn: unknown log field %q", name)
+		}
+		if name == "time" || t.Field(i).Type.Kind() != reflect.String {
+			return nil, fmt.Errorf("maxcdn: log field %q can't be hashed", name)
+		}
+		a.hash[name] = i
+	}
+	return a, nil
+}
+
+// Anonymize returns an anonymized copy of r.
+func (a *Anonymizer) Anonymize(r LogRecord) LogRecord {
+	r.ClientIP = a.truncateIP(r.ClientIP)
+
+	r.ClientLatitude = a.coarsen(r.ClientLatitude)
+	r.ClientLongitude = a.coarsen(r.ClientLongitude)
+
+	if a.dropCity {
+		r.ClientCity, r.ClientDma = "", ""
+	}
+
+	if len(a.deny) > 0 {
+		r.QueryString = a.stripQuery(r.QueryString)
+		r.URI = a.stripURL(r.URI)
+		r.Referer = a.stripURL(r.Referer)
+	}
+
+	if len(a.hash) > 0 {
+		v := reflect.ValueOf(&r).Elem()
+		for _, i := range a.hash {
+			if s := v.Field(i).String(); s != "" {
+				v.Field(i).SetString(a.hmac(s))
+			}
+		}
+	}
+	return r
+}
+
+// truncateIP zeroes the host bits of an IP. Unparsable IPs are cleared.
+func (a *Anonymizer) truncateIP(s string) string {
+	if s == "" {
+		return ""
+	}
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return ""
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4.Mask(a.ipv4Mask).String()
+	}
+	return ip.Mask(a.ipv6Mask).String()
+}
+
+// coarsen rounds a coordinate to the grid. Dividing by the inverse of the
+// grid, rather than multiplying by it, keeps grids such as 0.1 exact.
+func (a *Anonymizer) coarsen(x float64) float64 {
+	return math.Floor(x/a.grid+0.5) / (1 / a.grid)
+}
+
+// stripURL removes the denied parameters from the query of a URI or URL.
+func (a *Anonymizer) stripURL(s string) string {
+	i := strings.IndexByte(s, '?')
+	if i < 0 {
+		return s
+	}
+
+	query, fragment := s[i+1:], ""
+	if j := strings.IndexByte(query, '#'); j >= 0 {
+		query, fragment = query[:j], query[j:]
+	}
+	if query = a.stripQuery(query); query != "" {
+		return s[:i+1] + query + fragment
+	}
+	return s[:i] + fragment
+}
+
+// stripQuery removes the denied parameters from a query string, keeping the
+// order and encoding of the others.
+func (a *Anonymizer) stripQuery(query string) string {
+	if query == "" {
+		return ""
+	}
+
+	prefix := ""
+	if query[0] == '?' {
+		prefix, query = "?", query[1:]
+	}
+
+	var kept []string
+	for _, param := range strings.Split(query, "&") {
+		if !a.denied(param) {
+			kept = append(kept, param)
+		}
+	}
+	if len(kept) == 0 {
+		return ""
+	}
+	return prefix + strings.Join(kept, "&")
+}
+
+func (a *Anonymizer) denied(param string) bool {
+	name := param
+	if i := strings.IndexByte(param, '='); i >= 0 {
+		name = param[:i]
+	}
+	if unescaped, err := url.QueryUnescape(name); err == nil {
+		name = unescaped
+	}
+
+	for _, deny := range a.deny {
+		if strings.EqualFold(name, deny) {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *Anonymizer) hmac(s string) string {
+	mac := hmac.New(sha256.New, a.key)
+	mac.Write([]byte(s))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+type anonymizeLogWriter struct {
+	LogWriter
+	anonymizer *Anonymizer
+}
+
+// AnonymizeLogWriter returns a LogWriter which anonymizes records with a
+// before writing them to w.
+func AnonymizeLogWriter(w LogWriter, a *Anonymizer) LogWriter {
+	return &anonymizeLogWriter{LogWriter: w, anonymizer: a}
+}
+
+func (w *anonymizeLogWriter) WriteRecord(r LogRecord) error {
+	return w.LogWriter.WriteRecord(w.anonymizer.Anonymize(r))
+}
@@ -0,0 +1,126 @@
+package maxcdn
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnonymizer(t *testing.T) {
+	assert := assert.New(t)
+
+	a, err := NewAnonymizer(AnonymizerOptions{
+		DropCity:   true,
+		DenyParams: []string{"email", "token"},
+	})
+	assert.Nil(err)
+
+	r := a.Anonymize(LogRecord{
+		ClientIP:        "119.130.187.162",
+		ClientLatitude:  33.956199645996094,
+		ClientLongitude: -118.35289764404297,
+		ClientCity:      "Los Angeles",
+		ClientDma:       "803",
+		ClientState:     "CA",
+		QueryString:     "a=1&Email=me%40example.com&b=2&token",
+		URI:             "/search?token=abc#top",
+		Referer:         "http://example.com/?q=x&%65mail=y",
+		UserAgent:       "curl/7.35.0",
+	})
+	assert.Equal("119.130.187.0", r.ClientIP)
+	assert.Equal(34.0, r.ClientLatitude)
+	assert.Equal(-118.4, r.ClientLongitude)
+	assert.Equal("", r.ClientCity)
+	assert.Equal("", r.ClientDma)
+	assert.Equal("CA", r.ClientState)
+	assert.Equal("a=1&b=2", r.QueryString)
+	assert.Equal("/search#top", r.URI)
+	assert.Equal("http://example.com/?q=x", r.Referer)
+	assert.Equal("curl/7.35.0", r.UserAgent)
+
+	for ip, want := range map[string]string{
+		"2001:db8:85a3:8d3:1319:8a2e:370:7348": "2001:db8:85a3::",
+		"::ffff:10.1.2.3":                      "10.1.2.0",
+		"bogus":                                "",
+		"":                                     "",
+	} {
+		assert.Equal(want, a.Anonymize(LogRecord{ClientIP: ip}).ClientIP, ip)
+	}
+
+	a, err = NewAnonymizer(AnonymizerOptions{IPv4Prefix: 16, CoordinateGrid: 1})
+	assert.Nil(err)
+	r = a.Anonymize(LogRecord{ClientIP: "119.130.187.162", ClientLatitude: 33.5, ClientCity: "Los Angeles", QueryString: "email=x"})
+	assert.Equal("119.130.0.0", r.ClientIP)
+	assert.Equal(34.0, r.ClientLatitude)
+	assert.Equal("Los Angeles", r.ClientCity)
+	assert.Equal("email=x", r.QueryString)
+}
+
+func TestAnonymizer_hash(t *testing.T) {
+	assert := assert.New(t)
+
+	a, err := NewAnonymizer(AnonymizerOptions{
+		HashFields: []string{"client_ip", "user_agent"},
+		HashKey:    []byte("secret"),
+	})
+	assert.Nil(err)
+
+	r1 := a.Anonymize(LogRecord{ClientIP: "119.130.187.162", UserAgent: "curl/7.35.0"})
+	r2 := a.Anonymize(LogRecord{ClientIP: "119.130.187.163"})
+	r3 := a.Anonymize(LogRecord{ClientIP: "119.130.188.162"})
+
+	// addresses are truncated before hashing
+	assert.Len(r1.ClientIP, 64)
+	assert.Equal(a.hmac("119.130.187.0"), r1.ClientIP)
+	assert.Equal(r1.ClientIP, r2.ClientIP)
+	assert.NotEqual(r1.ClientIP, r3.ClientIP)
+	assert.Len(r1.UserAgent, 64)
+	assert.Equal("", r2.UserAgent)
+
+	other, err := NewAnonymizer(AnonymizerOptions{HashFields: []string{"client_ip"}, HashKey: []byte("other")})
+	assert.Nil(err)
+	assert.NotEqual(r1.ClientIP, other.Anonymize(LogRecord{ClientIP: "119.130.187.162"}).ClientIP)
+
+	// as are denied params stripped
+	a, err = NewAnonymizer(AnonymizerOptions{
+		DenyParams: []string{"email"},
+		HashFields: []string{"query_string"},
+		HashKey:    []byte("secret"),
+	})
+	assert.Nil(err)
+	assert.Equal(a.hmac("a=1"), a.Anonymize(LogRecord{QueryString: "a=1&email=me%40example.com"}).QueryString)
+}
+
+func TestNewAnonymizer_errors(t *testing.T) {
+	for _, opts := range []AnonymizerOptions{
+		{IPv4Prefix: 33},
+		{IPv6Prefix: -1},
+		{CoordinateGrid: -0.1},
+		{HashFields: []string{"client_ip"}},
+		{HashFields: []string{"bogus"}, HashKey: []byte("secret")},
+		{HashFields: []string{"status"}, HashKey: []byte("secret")},
+		{HashFields: []string{"time"}, HashKey: []byte("secret")},
+	} {
+		_, err := NewAnonymizer(opts)
+		assert.NotNil(t, err, "%+v", opts)
+	}
+}
+
+func TestAnonymizeLogWriter(t *testing.T) {
+	assert := assert.New(t)
+	max := NewMaxCDN("alias", "token", "secret")
+	max.HTTPClient = stubHTTPOk()
+
+	a, err := NewAnonymizer(AnonymizerOptions{DropCity: true})
+	assert.Nil(err)
+
+	var buf bytes.Buffer
+	n, err := max.IterateLogs(LogsQuery{}).Pipe(AnonymizeLogWriter(NewNDJSONLogWriter(&buf), a))
+	assert.Nil(err)
+	assert.Equal(54, n)
+	assert.NotContains(buf.String(), "119.130.187.162")
+	assert.Contains(buf.String(), `"client_ip":"119.130.187.0"`)
+	assert.Equal(54, strings.Count(buf.String(), `"client_city":""`))
+}
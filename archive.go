@@ -0,0 +1,325 @@
+package maxcdn
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// DefaultArchiveWindow is the longest time window a LogArchiver queries at
+// once when LogArchiverOptions.Window is not set.
+const DefaultArchiveWindow = time.Hour
+
+// ArchiveCheckpointFile is the name of the checkpoint in the archive
+// directory.
+const ArchiveCheckpointFile = "checkpoint.json"
+
+// archiveInvalidTimeFile is the name of the file in a zone's directory
+// holding the records whose time doesn't parse.
+const archiveInvalidTimeFile = "invalid-time.ndjson.gz"
+
+// LogArchiverOptions configures a LogArchiver.
+type LogArchiverOptions struct {
+
+	// Query filters the archived records. Its Start is where a new archive
+	// starts, defaulting to one Window before the ingestion delay. End and
+	// PageKey are ignored.
+	Query LogsQuery
+
+	// Window is the time window queried at once, at most MaxLogsWindow.
+	Window time.Duration
+
+	// Delay is how long records are given to show up in the logs API,
+	// twice LogsIngestionDelay when zero, as that's how late TailLogs
+	// expects records to arrive. Records newer than Delay aren't archived
+	// yet, and windows aren't queried again once archived, so records
+	// arriving later than Delay are missed.
+	Delay time.Duration
+
+	// OnError is called with the errors of periodic runs. When nil, Run
+	// returns the first error.
+	OnError func(error)
+}
+
+// ArchiveCheckpoint is the progress of an archive. Every record before Time
+// is archived, as are the pages of the window from Time to End before
+// PageKey.
+type ArchiveCheckpoint struct {
+	Time    time.Time `json:"time"`
+	End     time.Time `json:"end"`
+	PageKey string    `json:"page_key,omitempty"`
+
+	// Pending holds the sizes of the files a page was being written to,
+	// which are truncated back when resuming after a failure.
+	Pending map[string]int64 `json:"pending,omitempty"`
+}
+
+// LogArchiver copies raw logs to gzip compressed NDJSON files, partitioned
+// by zone and hour, as in DIR/164197/2014/07/01/16.ndjson.gz. Records whose
+// time doesn't parse go to DIR/164197/invalid-time.ndjson.gz. Its progress
+// is checkpointed after every page, so an interrupted archive resumes
+// without duplicating or skipping records.
+//
+// A LogArchiver isn't safe for concurrent use, and a directory should only
+// be archived to by one LogArchiver at a time.
+type LogArchiver struct {
+	max  *MaxCDN
+	dir  string
+	opts LogArchiverOptions
+	now  func() time.Time
+}
+
+// NewLogArchiver sets up a LogArchiver writing to dir.
+func NewLogArchiver(max *MaxCDN, dir string, opts LogArchiverOptions) *LogArchiver {
+	if opts.Window <= 0 || opts.Window > MaxLogsWindow {
+		opts.Window = DefaultArchiveWindow
+	}
+	if opts.Delay <= 0 {
+		opts.Delay = 2 * LogsIngestionDelay
+	}
+	return &LogArchiver{max: max, dir: dir, opts: opts, now: time.Now}
+}
+
+// Run archives every interval until ctx is done.
+func (a *LogArchiver) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := a.Archive(); err != nil {
+			if a.opts.OnError == nil {
+				return err
+			}
+			a.opts.OnError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Archive copies the records ingested since the checkpoint, and returns the
+// number of records written.
+func (a *LogArchiver) Archive() (int, error) {
+	cp, err := a.Checkpoint()
+	if err != nil {
+		return 0, err
+	}
+
+	if len(cp.Pending) > 0 {
+		if err := a.rollback(cp.Pending); err != nil {
+			return 0, err
+		}
+		cp.Pending = nil
+	}
+
+	var written int
+	until := a.now().Add(-a.opts.Delay).Truncate(time.Second)
+	for {
+		if cp.End.IsZero() {
+			cp.End = cp.Time.Add(a.opts.Window)
+			if cp.End.After(until) {
+				cp.End = until
+			}
+			if !cp.End.After(cp.Time) {
+				return written, nil
+			}
+		}
+
+		n, err := a.archiveWindow(&cp)
+		written += n
+		if err != nil {
+			return written, err
+		}
+	}
+}
+
+// archiveWindow archives the window of cp from its page key, and moves the
+// checkpoint past it.
+func (a *LogArchiver) archiveWindow(cp *ArchiveCheckpoint) (int, error) {
+	q := a.opts.Query
+	q.Start, q.End, q.PageKey = cp.Time, cp.End, cp.PageKey
+
+	var written int
+	for {
+		logs, err := a.max.GetLogsQuery(q)
+		if err != nil {
+			return written, err
+		}
+
+		files := map[string][]LogRecord{}
+		for _, r := range logs.Records {
+			// Records without a valid time are kept, as the API placed
+			// them in this window.
+			ts := r.Timestamp()
+			if !ts.IsZero() && (ts.Before(cp.Time) || !ts.Before(cp.End)) || !q.Filter.Match(&r) {
+				continue
+			}
+			file := a.file(r)
+			files[file] = append(files[file], r)
+		}
+
+		if len(files) > 0 {
+			if err := a.writePage(cp, files); err != nil {
+				return written, err
+			}
+			for _, records := range files {
+				written += len(records)
+			}
+		}
+
+		// Paging stops as in LogIterator.
+		limit := logs.Limit
+		if limit <= 0 {
+			limit = q.Limit
+		}
+		next := logs.NextPageKey
+		if next == "" || next == q.PageKey || len(logs.Records) < limit {
+			*cp = ArchiveCheckpoint{Time: cp.End}
+			return written, a.saveCheckpoint(*cp)
+		}
+
+		q.PageKey = next
+		cp.PageKey = next
+		if err := a.saveCheckpoint(*cp); err != nil {
+			return written, err
+		}
+	}
+}
+
+// writePage appends records to their files, checkpointing the file sizes
+// first so a failed write can be rolled back.
+func (a *LogArchiver) writePage(cp *ArchiveCheckpoint, files map[string][]LogRecord) error {
+	names := make([]string, 0, len(files))
+	cp.Pending = make(map[string]int64, len(files))
+	for name := range files {
+		names = append(names, name)
+
+		var size int64
+		if fi, err := os.Stat(filepath.Join(a.dir, name)); err == nil {
+			size = fi.Size()
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+		cp.Pending[name] = size
+	}
+	if err := a.saveCheckpoint(*cp); err != nil {
+		return err
+	}
+
+	sort.Strings(names)
+	for _, name := range names {
+		if err := appendGzipRecords(filepath.Join(a.dir, name), files[name]); err != nil {
+			return err
+		}
+	}
+	cp.Pending = nil
+	return nil
+}
+
+// appendGzipRecords appends records to a file as a new gzip member, which
+// gzip readers read as a continuation of the earlier members.
+func appendGzipRecords(filename string, records []LogRecord) error {
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(f)
+	w := NewNDJSONLogWriter(gz)
+	for _, r := range records {
+		if err = w.WriteRecord(r); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if cerr := gz.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// rollback truncates files to their sizes before an interrupted page.
+func (a *LogArchiver) rollback(sizes map[string]int64) error {
+	for name, size := range sizes {
+		filename := filepath.Join(a.dir, name)
+		if size == 0 {
+			if err := os.Remove(filename); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			continue
+		}
+		if err := os.Truncate(filename, size); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// file returns the path of a record's file, relative to the archive.
+func (a *LogArchiver) file(r LogRecord) string {
+	ts := r.Timestamp()
+	if ts.IsZero() {
+		return filepath.Join(strconv.Itoa(r.ZoneID), archiveInvalidTimeFile)
+	}
+	return filepath.Join(strconv.Itoa(r.ZoneID), ts.UTC().Format("2006/01/02/15")+".ndjson.gz")
+}
+
+// Checkpoint returns the archive's checkpoint, or a new one starting at
+// the query's Start.
+func (a *LogArchiver) Checkpoint() (ArchiveCheckpoint, error) {
+	var cp ArchiveCheckpoint
+
+	buf, err := ioutil.ReadFile(filepath.Join(a.dir, ArchiveCheckpointFile))
+	if os.IsNotExist(err) {
+		cp.Time = a.opts.Query.Start
+		if cp.Time.IsZero() {
+			cp.Time = a.now().Add(-a.opts.Delay - a.opts.Window)
+		}
+		cp.Time = cp.Time.UTC().Truncate(time.Second)
+		return cp, nil
+	} else if err != nil {
+		return cp, err
+	}
+
+	err = json.Unmarshal(buf, &cp)
+	return cp, err
+}
+
+// saveCheckpoint replaces the checkpoint atomically.
+func (a *LogArchiver) saveCheckpoint(cp ArchiveCheckpoint) error {
+	buf, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(a.dir, 0755); err != nil {
+		return err
+	}
+
+	filename := filepath.Join(a.dir, ArchiveCheckpointFile)
+	tmp := filename + ".tmp"
+	if err := ioutil.WriteFile(tmp, buf, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filename)
+}
@@ -0,0 +1,189 @@
+package maxcdn
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// readArchive returns the URIs of an archive file.
+func readArchive(t *testing.T, filename string) []string {
+	f, err := os.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf, err := ioutil.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var uris []string
+	for _, line := range strings.Split(strings.TrimSpace(string(buf)), "\n") {
+		var r LogRecord
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatal(err)
+		}
+		uris = append(uris, r.URI)
+	}
+	return uris
+}
+
+func TestLogArchiver(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "maxcdn-archive")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+
+	max := NewMaxCDN("alias", "token", "secret")
+	api := &stubLogsAPI{}
+	max.HTTPClient = api.client()
+
+	t0 := time.Date(2014, 7, 1, 15, 0, 0, 0, time.UTC)
+	now := t0.Add(90 * time.Minute)
+
+	a := NewLogArchiver(max, dir, LogArchiverOptions{Query: LogsQuery{Start: t0}, Delay: time.Minute})
+	a.now = func() time.Time { return now }
+
+	api.ingest("/a", t0.Add(10*time.Minute))
+	api.ingest("/b", t0.Add(70*time.Minute))
+	api.ingest("/c", t0.Add(89*time.Minute+30*time.Second))
+
+	n, err := a.Archive()
+	assert.Nil(err)
+	assert.Equal(2, n)
+	assert.Equal([][2]string{
+		{"2014-07-01T15:00:00Z", "2014-07-01T16:00:00Z"},
+		{"2014-07-01T16:00:00Z", "2014-07-01T16:29:00Z"},
+	}, api.windows)
+
+	hour15 := filepath.Join(dir, "0", "2014", "07", "01", "15.ndjson.gz")
+	hour16 := filepath.Join(dir, "0", "2014", "07", "01", "16.ndjson.gz")
+	assert.Equal([]string{"/a"}, readArchive(t, hour15))
+	assert.Equal([]string{"/b"}, readArchive(t, hour16))
+
+	cp, err := a.Checkpoint()
+	assert.Nil(err)
+	assert.Equal(ArchiveCheckpoint{Time: t0.Add(89 * time.Minute)}, cp)
+
+	// a restarted archiver picks up from the checkpoint
+	now = now.Add(10 * time.Minute)
+	a = NewLogArchiver(max, dir, LogArchiverOptions{Delay: time.Minute})
+	a.now = func() time.Time { return now }
+
+	n, err = a.Archive()
+	assert.Nil(err)
+	assert.Equal(1, n)
+	assert.Equal([]string{"/b", "/c"}, readArchive(t, hour16))
+}
+
+func TestLogArchiver_invalidTime(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "maxcdn-archive")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+
+	t0 := time.Date(2014, 7, 1, 16, 0, 0, 0, time.UTC)
+	max := NewMaxCDN("alias", "token", "secret")
+	max.HTTPClient = stubHTTPFunc(func(r *http.Request) (*http.Response, error) {
+		return stubBodyResponse(r, 200, `{"limit":100,"records":[`+
+			`{"zone_id":1,"uri":"/a","time":"2014-07-01T16:10:00Z"},`+
+			`{"zone_id":1,"uri":"/b","time":"bogus"}]}`), nil
+	})
+
+	a := NewLogArchiver(max, dir, LogArchiverOptions{Query: LogsQuery{Start: t0}})
+	assert.Equal(2*LogsIngestionDelay, a.opts.Delay)
+	a.now = func() time.Time { return t0.Add(30 * time.Minute) }
+
+	n, err := a.Archive()
+	assert.Nil(err)
+	assert.Equal(2, n)
+	assert.Equal([]string{"/a"}, readArchive(t, filepath.Join(dir, "1", "2014", "07", "01", "16.ndjson.gz")))
+	assert.Equal([]string{"/b"}, readArchive(t, filepath.Join(dir, "1", "invalid-time.ndjson.gz")))
+
+	cp, err := a.Checkpoint()
+	assert.Nil(err)
+	assert.Equal(ArchiveCheckpoint{Time: t0.Add(28 * time.Minute)}, cp)
+}
+
+func TestLogArchiver_resume(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "maxcdn-archive")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+
+	t0 := time.Date(2014, 7, 1, 16, 0, 0, 0, time.UTC)
+	record := func(uri string, minute int) string {
+		return `{"zone_id":1,"uri":"` + uri + `","time":"` + t0.Add(time.Duration(minute)*time.Minute).Format(time.RFC3339) + `"}`
+	}
+	pages := map[string]string{
+		"":   `{"limit":2,"next_page_key":"k1","records":[` + record("/1", 1) + `,` + record("/2", 2) + `]}`,
+		"k1": `{"limit":2,"next_page_key":"k2","records":[` + record("/3", 3) + `,` + record("/4", 4) + `]}`,
+		"k2": `{"limit":2,"next_page_key":"k3","records":[` + record("/5", 5) + `]}`,
+	}
+
+	max := NewMaxCDN("alias", "token", "secret")
+	var keys []string
+	fail := "k2"
+	max.HTTPClient = stubHTTPFunc(func(r *http.Request) (*http.Response, error) {
+		key := r.URL.Query().Get("page_key")
+		keys = append(keys, key)
+		if key == fail {
+			return stubBodyResponse(r, 502, "Bad Gateway"), nil
+		}
+		return stubBodyResponse(r, 200, pages[key]), nil
+	})
+
+	a := NewLogArchiver(max, dir, LogArchiverOptions{Query: LogsQuery{Start: t0}, Delay: time.Minute})
+	a.now = func() time.Time { return t0.Add(30 * time.Minute) }
+
+	n, err := a.Archive()
+	assert.NotNil(err)
+	assert.Equal(4, n)
+
+	cp, err := a.Checkpoint()
+	assert.Nil(err)
+	assert.Equal("k2", cp.PageKey)
+	assert.Equal(t0.Add(29*time.Minute), cp.End)
+
+	// Simulate a crash while writing page k2, after its sizes were
+	// checkpointed.
+	file := filepath.Join(dir, "1", "2014", "07", "01", "16.ndjson.gz")
+	fi, err := os.Stat(file)
+	assert.Nil(err)
+	cp.Pending = map[string]int64{filepath.Join("1", "2014", "07", "01", "16.ndjson.gz"): fi.Size()}
+	assert.Nil(a.saveCheckpoint(cp))
+
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_APPEND, 0644)
+	assert.Nil(err)
+	f.Write([]byte("partial gzip member"))
+	f.Close()
+
+	fail = ""
+	keys = nil
+	n, err = a.Archive()
+	assert.Nil(err)
+	assert.Equal(1, n)
+	assert.Equal([]string{"k2"}, keys)
+	assert.Equal([]string{"/1", "/2", "/3", "/4", "/5"}, readArchive(t, file))
+
+	cp, err = a.Checkpoint()
+	assert.Nil(err)
+	assert.Equal(ArchiveCheckpoint{Time: t0.Add(29 * time.Minute)}, cp)
+}
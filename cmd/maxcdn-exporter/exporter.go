@@ -0,0 +1,295 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MaxCDN/go-maxcdn"
+)
+
+// family is a metric family in the Prometheus text exposition format.
+type family struct {
+	help    string
+	kind    string
+	samples map[string]float64
+}
+
+// registry holds the metric families served on /metrics.
+type registry struct {
+	mu       sync.Mutex
+	families map[string]*family
+}
+
+func newRegistry() *registry {
+	return &registry{families: map[string]*family{}}
+}
+
+func (r *registry) family(name, kind, help string) *family {
+	f, ok := r.families[name]
+	if !ok {
+		f = &family{help: help, kind: kind, samples: map[string]float64{}}
+		r.families[name] = f
+	}
+	return f
+}
+
+// set sets a sample. labels are key, value pairs.
+func (r *registry) set(name, kind, help string, value float64, labels ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.family(name, kind, help).samples[formatLabels(labels)] = value
+}
+
+// add adds to a sample. labels are key, value pairs.
+func (r *registry) add(name, kind, help string, value float64, labels ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.family(name, kind, help).samples[formatLabels(labels)] += value
+}
+
+// update is a new set of samples for a family, built without holding the
+// registry lock.
+type update struct {
+	name, kind, help string
+	samples          map[string]float64
+}
+
+func newUpdate(name, kind, help string) *update {
+	return &update{name: name, kind: kind, help: help, samples: map[string]float64{}}
+}
+
+// set sets a sample. labels are key, value pairs.
+func (u *update) set(value float64, labels ...string) {
+	u.samples[formatLabels(labels)] = value
+}
+
+// add adds to a sample. labels are key, value pairs.
+func (u *update) add(value float64, labels ...string) {
+	u.samples[formatLabels(labels)] += value
+}
+
+// replace drops every sample of the updated families matching labels and
+// stores the updates in their place, under a single lock so scrapes never
+// see the series missing. Dropping matching samples makes stale series,
+// such as files which left the top-N, disappear.
+func (r *registry) replace(labels []string, updates ...*update) {
+	prefix := strings.TrimSuffix(formatLabels(labels), "}")
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, u := range updates {
+		f := r.family(u.name, u.kind, u.help)
+		for key := range f.samples {
+			if strings.HasPrefix(key, prefix) {
+				delete(f.samples, key)
+			}
+		}
+		for key, value := range u.samples {
+			f.samples[key] = value
+		}
+	}
+}
+
+// WriteTo writes every family in the text exposition format, sorted by name
+// and labels.
+func (r *registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := make([]string, 0, len(r.families))
+	for name := range r.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	for _, name := range names {
+		f := r.families[name]
+		fmt.Fprintf(&buf, "# HELP %s %s\n", name, f.help)
+		fmt.Fprintf(&buf, "# TYPE %s %s\n", name, f.kind)
+
+		keys := make([]string, 0, len(f.samples))
+		for key := range f.samples {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			fmt.Fprintf(&buf, "%s%s %s\n", name, key, formatValue(f.samples[key]))
+		}
+	}
+	return buf.WriteTo(w)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func formatLabels(labels []string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	pairs := make([]string, 0, len(labels)/2)
+	for i := 0; i+1 < len(labels); i += 2 {
+		pairs = append(pairs, labels[i]+`="`+labelEscaper.Replace(labels[i+1])+`"`)
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// exporter polls the MaxCDN reports and keeps their metrics up to date.
+type exporter struct {
+	max   *maxcdn.MaxCDN
+	zones []int
+	top   int
+	reg   *registry
+	now   func() time.Time
+}
+
+func newExporter(max *maxcdn.MaxCDN, zones []int, top int) *exporter {
+	if len(zones) == 0 {
+		zones = []int{0}
+	}
+	return &exporter{
+		max:   max,
+		zones: zones,
+		top:   top,
+		reg:   newRegistry(),
+		now:   time.Now,
+	}
+}
+
+// run polls every interval until stop is closed.
+func (e *exporter) run(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		e.poll()
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// poll fetches every report for every zone once.
+func (e *exporter) poll() {
+	for _, zone := range e.zones {
+		start := e.now()
+		ok := e.pollZone(zone)
+
+		label := zoneLabel(zone)
+		e.reg.set("maxcdn_scrape_success", "gauge",
+			"Whether the last poll of every report of a zone succeeded.", boolValue(ok), "zone", label)
+		e.reg.set("maxcdn_scrape_duration_seconds", "gauge",
+			"Duration of the last poll of a zone.", e.now().Sub(start).Seconds(), "zone", label)
+		e.reg.set("maxcdn_scrape_timestamp_seconds", "gauge",
+			"Unix time of the last poll of a zone.", float64(start.Unix()), "zone", label)
+	}
+}
+
+func (e *exporter) pollZone(zone int) bool {
+	var (
+		ok    = true
+		label = zoneLabel(zone)
+		q     = maxcdn.StatsQuery{Zone: zone}
+	)
+
+	var stats *maxcdn.Stats
+	if e.call("stats", zone, func() (err error) {
+		stats, err = e.max.GetStats(q)
+		return err
+	}) {
+		for _, m := range maxcdn.StatsMetrics {
+			e.reg.set("maxcdn_"+string(m), "gauge",
+				"Stats report "+string(m)+" counter.", float64(stats.Summary.Value(m)), "zone", label)
+		}
+		e.reg.set("maxcdn_cache_hit_ratio", "gauge",
+			"Fraction of hits served from cache.", stats.Summary.CacheHitRatio(), "zone", label)
+	} else {
+		ok = false
+	}
+
+	var codes []maxcdn.StatusCodeStat
+	if e.call("statuscodes", zone, func() (err error) {
+		codes, err = e.max.StatusCodes(q)
+		return err
+	}) {
+		hits := newUpdate("maxcdn_status_code_hits", "gauge", "Status codes report hits.")
+		for _, code := range codes {
+			hits.add(float64(code.Hits), "zone", label, "status_code", strconv.Itoa(code.StatusCode))
+		}
+		e.reg.replace([]string{"zone", label}, hits)
+	} else {
+		ok = false
+	}
+
+	var popular *maxcdn.PopularFilesReport
+	if e.call("popularfiles", zone, func() (err error) {
+		popular, err = e.max.PopularFiles(maxcdn.PopularFilesQuery{StatsQuery: q, Top: e.top})
+		return err
+	}) {
+		fileHits := newUpdate("maxcdn_popular_file_hits", "gauge", "Popular files report hits.")
+		fileBytes := newUpdate("maxcdn_popular_file_bytes", "gauge", "Popular files report bytes.")
+		for _, file := range popular.Files {
+			fileHits.set(float64(file.Hits), "zone", label, "uri", file.URI)
+			fileBytes.set(float64(file.Bytes), "zone", label, "uri", file.URI)
+		}
+		e.reg.replace([]string{"zone", label}, fileHits, fileBytes)
+	} else {
+		ok = false
+	}
+
+	return ok
+}
+
+// call runs a single API call, recording its latency and errors.
+func (e *exporter) call(report string, zone int, fn func() error) bool {
+	start := e.now()
+	err := fn()
+	elapsed := e.now().Sub(start).Seconds()
+
+	labels := []string{"report", report, "zone", zoneLabel(zone)}
+	e.reg.add("maxcdn_api_request_seconds_total", "counter",
+		"Total time spent in API requests.", elapsed, labels...)
+	e.reg.add("maxcdn_api_requests_total", "counter",
+		"Total number of API requests.", 1, labels...)
+	e.reg.add("maxcdn_api_errors_total", "counter",
+		"Total number of failed API requests.", boolValue(err != nil), labels...)
+
+	return err == nil
+}
+
+func zoneLabel(zone int) string {
+	if zone == 0 {
+		return ""
+	}
+	return strconv.Itoa(zone)
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MaxCDN/go-maxcdn"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeAPI serves canned reports for zone 123 and errors for every other
+// zone.
+func fakeAPI() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if !strings.HasPrefix(r.URL.Path, "/alias/reports/123/") {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":400,"error":{"type":"bad_request","message":"unknown zone"}}`))
+			return
+		}
+
+		switch strings.TrimPrefix(r.URL.Path, "/alias/reports/123/") {
+		case "stats.json":
+			w.Write([]byte(`{"code":200,"data":{"stats":{"cache_hit":"75","hit":"100","noncache_hit":"25","size":"2048"},"total":"1"}}`))
+		case "statuscodes.json":
+			w.Write([]byte(`{"code":200,"data":{"statuscodes":[{"status_code":"200","hit":"90"},{"status_code":"404","hit":"10"}]}}`))
+		case "popularfiles.json":
+			w.Write([]byte(`{"code":200,"data":{"popularfiles":[{"uri":"/a \"b\".css","hit":"60","size":"600"}],"summary":{"hit":null,"size":null}}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestExporter(t *testing.T) {
+	assert := assert.New(t)
+
+	server := fakeAPI()
+	defer server.Close()
+
+	host := maxcdn.APIHost
+	maxcdn.APIHost = server.URL
+	defer func() { maxcdn.APIHost = host }()
+
+	max := maxcdn.NewMaxCDN("alias", "token", "secret")
+	e := newExporter(max, []int{123, 456}, 5)
+	e.poll()
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal("text/plain; version=0.0.4; charset=utf-8", rec.Header().Get("Content-Type"))
+
+	body, _ := ioutil.ReadAll(rec.Body)
+	metrics := string(body)
+
+	for _, line := range []string{
+		"# HELP maxcdn_hits Stats report hits counter.",
+		"# TYPE maxcdn_hits gauge",
+		`maxcdn_hits{zone="123"} 100`,
+		`maxcdn_bytes{zone="123"} 2048`,
+		`maxcdn_cache_hit_ratio{zone="123"} 0.75`,
+		`maxcdn_status_code_hits{zone="123",status_code="404"} 10`,
+		`maxcdn_popular_file_hits{zone="123",uri="/a \"b\".css"} 60`,
+		`maxcdn_scrape_success{zone="123"} 1`,
+		`maxcdn_scrape_success{zone="456"} 0`,
+		"# TYPE maxcdn_api_errors_total counter",
+		`maxcdn_api_errors_total{report="stats",zone="123"} 0`,
+		`maxcdn_api_errors_total{report="stats",zone="456"} 1`,
+		`maxcdn_api_requests_total{report="popularfiles",zone="456"} 1`,
+	} {
+		assert.Contains(metrics, line+"\n")
+	}
+	assert.NotContains(metrics, `maxcdn_hits{zone="456"}`)
+
+	// counters accumulate across polls
+	e.poll()
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Contains(rec.Body.String(), `maxcdn_api_errors_total{report="stats",zone="456"} 2`+"\n")
+	assert.Contains(rec.Body.String(), `maxcdn_api_requests_total{report="stats",zone="123"} 2`+"\n")
+}
+
+func TestRegistry_replace(t *testing.T) {
+	assert := assert.New(t)
+
+	reg := newRegistry()
+	reg.set("m", "gauge", "help", 1, "zone", "1", "uri", "/a")
+	reg.set("m", "gauge", "help", 2, "zone", "10", "uri", "/a")
+
+	u := newUpdate("m", "gauge", "help")
+	u.set(3, "zone", "1", "uri", "/b")
+	u.add(1, "zone", "1", "uri", "/c")
+	u.add(1, "zone", "1", "uri", "/c")
+	reg.replace([]string{"zone", "1"}, u)
+
+	var buf bytes.Buffer
+	reg.WriteTo(&buf)
+	assert.Equal("# HELP m help\n# TYPE m gauge\n"+
+		"m{zone=\"1\",uri=\"/b\"} 3\n"+
+		"m{zone=\"1\",uri=\"/c\"} 2\n"+
+		"m{zone=\"10\",uri=\"/a\"} 2\n", buf.String())
+}
+
+func TestParseZones(t *testing.T) {
+	assert := assert.New(t)
+
+	zones, err := parseZones(" 123, 456,,")
+	assert.Nil(err)
+	assert.Equal([]int{123, 456}, zones)
+
+	zones, err = parseZones("")
+	assert.Nil(err)
+	assert.Empty(zones)
+
+	_, err = parseZones("abc")
+	assert.NotNil(err)
+}
@@ -0,0 +1,80 @@
+// Command maxcdn-exporter serves MaxCDN report metrics to Prometheus.
+//
+// It polls the stats, status codes and popular files reports of the
+// configured zones on an interval, and serves the latest values on /metrics
+// in the Prometheus text exposition format.
+//
+// Usage:
+//
+//	$ ALIAS=your_alias TOKEN=your_token SECRET=your_secret \
+//	    maxcdn-exporter -zones 123456,234567 -interval 5m -listen :9491
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MaxCDN/go-maxcdn"
+)
+
+func main() {
+	var (
+		alias    = flag.String("alias", os.Getenv("ALIAS"), "MaxCDN consumer alias, defaults to $ALIAS")
+		token    = flag.String("token", os.Getenv("TOKEN"), "MaxCDN consumer token, defaults to $TOKEN")
+		secret   = flag.String("secret", os.Getenv("SECRET"), "MaxCDN consumer secret, defaults to $SECRET")
+		zones    = flag.String("zones", "", "comma separated zone IDs, the whole account when empty")
+		interval = flag.Duration("interval", 5*time.Minute, "report polling interval")
+		top      = flag.Int("top", 10, "number of popular files to export per zone")
+		listen   = flag.String("listen", ":9491", "address to serve /metrics on")
+	)
+	flag.Parse()
+
+	if *alias == "" || *token == "" || *secret == "" {
+		fmt.Fprintln(os.Stderr, "alias, token and secret are required")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	zoneIDs, err := parseZones(*zones)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	e := newExporter(maxcdn.NewMaxCDN(*alias, *token, *secret), zoneIDs, *top)
+	go e.run(*interval, nil)
+
+	http.Handle("/metrics", e)
+	log.Printf("serving metrics on %s/metrics", *listen)
+	log.Fatal(http.ListenAndServe(*listen, nil))
+}
+
+// ServeHTTP serves the latest metrics.
+func (e *exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if _, err := e.reg.WriteTo(w); err != nil {
+		log.Printf("writing metrics: %s", err)
+	}
+}
+
+func parseZones(s string) ([]int, error) {
+	var zones []int
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		zone, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid zone %q", field)
+		}
+		zones = append(zones, zone)
+	}
+	return zones, nil
+}
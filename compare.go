@@ -0,0 +1,263 @@
+package maxcdn
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// Comparison lines up the stats of several zones or periods. The first
+// series is the baseline the others are compared to.
+type Comparison struct {
+	Interval StatsInterval
+
+	// Labels name the compared series, such as "123" for a zone or
+	// "previous" and "current" for periods.
+	Labels []string
+
+	// Totals holds the summary of every series, and TotalChanges the change
+	// of every total but the first from the baseline.
+	Totals       []StatsSummary
+	TotalChanges [][]MetricChange
+
+	// Rows is empty when no interval was requested.
+	Rows []ComparisonRow
+}
+
+// ComparisonRow holds the aligned points of every series.
+type ComparisonRow struct {
+
+	// Timestamps holds the start of every point. It's zero for series
+	// without a point in the row, whose Values are then zero too.
+	Timestamps []time.Time
+	Values     []StatsSummary
+
+	// Changes holds the change of every value but the first from the
+	// baseline.
+	Changes [][]MetricChange
+}
+
+// Timestamp returns the first non-zero timestamp of the row.
+func (r ComparisonRow) Timestamp() time.Time {
+	for _, t := range r.Timestamps {
+		if !t.IsZero() {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// CompareZones fetches the stats report of q for every zone in parallel, and
+// compares them to the first zone. Points are aligned by timestamp.
+func (max *MaxCDN) CompareZones(q StatsQuery, zones ...int) (*Comparison, error) {
+	if len(zones) == 0 {
+		return nil, fmt.Errorf("maxcdn: no zones to compare")
+	}
+
+	queries := make([]StatsQuery, len(zones))
+	labels := make([]string, len(zones))
+	for i, zone := range zones {
+		queries[i] = q
+		queries[i].Zone = zone
+		labels[i] = strconv.Itoa(zone)
+	}
+
+	stats, err := max.getStatsParallel(queries)
+	if err != nil {
+		return nil, err
+	}
+	return compareStats(q.Interval, labels, stats, func(_ int, t time.Time) int64 {
+		return t.UnixNano()
+	}), nil
+}
+
+// ComparePeriods fetches the stats reports of two periods in parallel, such
+// as this week and last week, and compares current to previous. Points are
+// aligned by their offset, in intervals, from the From date of their query,
+// or from their first point when From is zero. The queries should share an
+// interval; the interval of current is used.
+func (max *MaxCDN) ComparePeriods(current, previous StatsQuery) (*Comparison, error) {
+	previous.Interval = current.Interval
+
+	stats, err := max.getStatsParallel([]StatsQuery{previous, current})
+	if err != nil {
+		return nil, err
+	}
+
+	origins := make([]time.Time, 2)
+	for i, q := range []StatsQuery{previous, current} {
+		origins[i] = q.From
+		if q.Location != nil {
+			origins[i] = q.From.In(q.Location)
+		}
+		if origins[i].IsZero() && len(stats[i].Series) > 0 {
+			sorted := append(StatsSeries(nil), stats[i].Series...)
+			sorted.sort()
+			origins[i] = sorted[0].Timestamp
+		}
+	}
+
+	labels := []string{"previous", "current"}
+	return compareStats(current.Interval, labels, stats, func(i int, t time.Time) int64 {
+		return intervalOffset(current.Interval, origins[i], t)
+	}), nil
+}
+
+// getStatsParallel fetches the reports of queries concurrently, returning
+// the first error by query order.
+func (max *MaxCDN) getStatsParallel(queries []StatsQuery) ([]*Stats, error) {
+	var (
+		stats = make([]*Stats, len(queries))
+		errs  = make([]error, len(queries))
+		wg    sync.WaitGroup
+	)
+	for i, q := range queries {
+		wg.Add(1)
+		go func(i int, q StatsQuery) {
+			defer wg.Done()
+			stats[i], errs[i] = max.GetStats(q)
+		}(i, q)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return stats, nil
+}
+
+// compareStats builds a comparison, aligning the points of series i by
+// key(i, timestamp).
+func compareStats(interval StatsInterval, labels []string, stats []*Stats, key func(int, time.Time) int64) *Comparison {
+	c := &Comparison{
+		Interval: interval,
+		Labels:   labels,
+		Totals:   make([]StatsSummary, len(stats)),
+	}
+	for i, s := range stats {
+		c.Totals[i] = s.Summary
+	}
+	c.TotalChanges = compareValues(c.Totals)
+
+	var keys []int64
+	rows := map[int64]*ComparisonRow{}
+	for i, s := range stats {
+		for _, p := range s.Series {
+			k := key(i, p.Timestamp)
+			row, ok := rows[k]
+			if !ok {
+				row = &ComparisonRow{
+					Timestamps: make([]time.Time, len(stats)),
+					Values:     make([]StatsSummary, len(stats)),
+				}
+				rows[k] = row
+				keys = append(keys, k)
+			}
+			if row.Timestamps[i].IsZero() {
+				row.Timestamps[i] = p.Timestamp
+			}
+			row.Values[i] = row.Values[i].Add(p.StatsSummary)
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	for _, k := range keys {
+		row := rows[k]
+		row.Changes = compareValues(row.Values)
+		c.Rows = append(c.Rows, *row)
+	}
+	return c
+}
+
+func compareValues(values []StatsSummary) [][]MetricChange {
+	if len(values) < 2 {
+		return nil
+	}
+	changes := make([][]MetricChange, 0, len(values)-1)
+	for _, v := range values[1:] {
+		changes = append(changes, Changes(values[0], v))
+	}
+	return changes
+}
+
+// intervalOffset returns the number of intervals from the start of origin's
+// interval to t, counted in wall clock time.
+func intervalOffset(interval StatsInterval, origin, t time.Time) int64 {
+	oy, om, od := origin.Date()
+	ty, tm, td := t.Date()
+
+	days := int64(time.Date(ty, tm, td, 0, 0, 0, 0, time.UTC).Sub(time.Date(oy, om, od, 0, 0, 0, 0, time.UTC)) / (24 * time.Hour))
+	switch interval {
+	case StatsHourly:
+		return days*24 + int64(t.Hour()-origin.Hour())
+	case StatsMonthly:
+		return int64(ty-oy)*12 + int64(tm-om)
+	}
+	return days
+}
+
+// WriteTable renders c as an aligned text table with a row per point and a
+// total row. Every metric gets a column per series, followed by the change
+// of every other series from the baseline. The metrics default to hits and
+// bytes.
+func (c *Comparison) WriteTable(w io.Writer, metrics ...StatsMetric) error {
+	if len(metrics) == 0 {
+		metrics = []StatsMetric{MetricHits, MetricBytes}
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+
+	header := []string{"timestamp"}
+	for _, m := range metrics {
+		for _, label := range c.Labels {
+			header = append(header, label+" "+string(m))
+		}
+		for _, label := range c.Labels[1:] {
+			header = append(header, label+" change", label+" %")
+		}
+	}
+	writeTableRow(tw, header)
+
+	for _, row := range c.Rows {
+		writeTableRow(tw, comparisonCells(formatTimestamp(row.Timestamp()), row.Values, row.Changes, metrics))
+	}
+	writeTableRow(tw, comparisonCells("total", c.Totals, c.TotalChanges, metrics))
+
+	return tw.Flush()
+}
+
+func comparisonCells(first string, values []StatsSummary, changes [][]MetricChange, metrics []StatsMetric) []string {
+	cells := []string{first}
+	for _, m := range metrics {
+		for _, v := range values {
+			cells = append(cells, strconv.FormatInt(v.Value(m), 10))
+		}
+		for _, cs := range changes {
+			for _, change := range cs {
+				if change.Metric == m {
+					cells = append(cells, strconv.FormatInt(change.Delta, 10), formatPercent(change.Percent))
+				}
+			}
+		}
+	}
+	return cells
+}
+
+func writeTableRow(w io.Writer, cells []string) {
+	fmt.Fprint(w, strings.Join(cells, "\t")+"\t\n")
+}
+
+func formatPercent(p float64) string {
+	if math.IsInf(p, 0) || math.IsNaN(p) {
+		return "n/a"
+	}
+	return strconv.FormatFloat(p, 'f', 1, 64) + "%"
+}
@@ -0,0 +1,140 @@
+package maxcdn
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// dailyStatsBody builds a daily stats report with a point per day from
+// from, holding hits and ten bytes per hit.
+func dailyStatsBody(from string, hits ...int) string {
+	start, _ := time.Parse("2006-01-02", from)
+
+	var points []string
+	for i, h := range hits {
+		points = append(points, fmt.Sprintf(`{"hit":"%d","cache_hit":"%d","noncache_hit":"0","size":"%d","timestamp":"%s"}`,
+			h, h, h*10, start.AddDate(0, 0, i).Format("2006-01-02")))
+	}
+	return `{"code":200,"data":{"page":1,"pages":1,"stats":[` + strings.Join(points, ",") + `]}}`
+}
+
+func TestMaxCDN_CompareZones(t *testing.T) {
+	assert := assert.New(t)
+	max := NewMaxCDN("alias", "token", "secret")
+
+	max.HTTPClient = stubHTTPFunc(func(r *http.Request) (*http.Response, error) {
+		switch r.URL.Path {
+		case "/alias/reports/1/stats.json/daily":
+			return stubBodyResponse(r, 200, dailyStatsBody("2014-06-01", 100, 200)), nil
+		case "/alias/reports/2/stats.json/daily":
+			return stubBodyResponse(r, 200, dailyStatsBody("2014-06-02", 300, 50)), nil
+		}
+		return stubBodyResponse(r, 400, stubAPIError), nil
+	})
+
+	c, err := max.CompareZones(StatsQuery{Interval: StatsDaily}, 1, 2)
+	assert.Nil(err)
+	assert.Equal([]string{"1", "2"}, c.Labels)
+	assert.Equal(int64(300), c.Totals[0].Hits)
+	assert.Equal(int64(350), c.Totals[1].Hits)
+	assert.Equal(int64(50), c.TotalChanges[0][0].Delta)
+	assert.InDelta(50.0/3, c.TotalChanges[0][0].Percent, 1e-9)
+
+	assert.Len(c.Rows, 3)
+
+	// zone 2 has no point on June 1st
+	first := c.Rows[0]
+	assert.Equal(time.Date(2014, 6, 1, 0, 0, 0, 0, time.UTC), first.Timestamp())
+	assert.True(first.Timestamps[1].IsZero())
+	assert.Equal(int64(-100), first.Changes[0][0].Delta)
+	assert.Equal(-100.0, first.Changes[0][0].Percent)
+
+	second := c.Rows[1]
+	assert.Equal(int64(200), second.Values[0].Hits)
+	assert.Equal(int64(300), second.Values[1].Hits)
+	assert.Equal(50.0, second.Changes[0][0].Percent)
+
+	// zone 1 has no point on June 3rd
+	assert.True(math.IsInf(c.Rows[2].Changes[0][0].Percent, 1))
+
+	_, err = max.CompareZones(StatsQuery{Interval: StatsDaily}, 1, 3)
+	assert.NotNil(err)
+
+	_, err = max.CompareZones(StatsQuery{})
+	assert.NotNil(err)
+}
+
+func TestMaxCDN_ComparePeriods(t *testing.T) {
+	assert := assert.New(t)
+	max := NewMaxCDN("alias", "token", "secret")
+
+	max.HTTPClient = stubHTTPFunc(func(r *http.Request) (*http.Response, error) {
+		switch r.URL.Query().Get("date_from") {
+		case "2014-06-01":
+			return stubBodyResponse(r, 200, dailyStatsBody("2014-06-01", 100, 200, 300)), nil
+		case "2014-06-08":
+			// no traffic on the first day
+			return stubBodyResponse(r, 200, dailyStatsBody("2014-06-09", 100, 600)), nil
+		}
+		return stubBodyResponse(r, 400, stubAPIError), nil
+	})
+
+	previous := StatsQuery{
+		Zone:     1,
+		Interval: StatsDaily,
+		From:     time.Date(2014, 6, 1, 0, 0, 0, 0, time.UTC),
+		To:       time.Date(2014, 6, 7, 0, 0, 0, 0, time.UTC),
+	}
+	current := previous
+	current.From = previous.From.AddDate(0, 0, 7)
+	current.To = previous.To.AddDate(0, 0, 7)
+
+	c, err := max.ComparePeriods(current, previous)
+	assert.Nil(err)
+	assert.Equal([]string{"previous", "current"}, c.Labels)
+	assert.Len(c.Rows, 3)
+
+	assert.Equal(time.Date(2014, 6, 1, 0, 0, 0, 0, time.UTC), c.Rows[0].Timestamps[0])
+	assert.True(c.Rows[0].Timestamps[1].IsZero())
+
+	assert.Equal(time.Date(2014, 6, 3, 0, 0, 0, 0, time.UTC), c.Rows[2].Timestamps[0])
+	assert.Equal(time.Date(2014, 6, 10, 0, 0, 0, 0, time.UTC), c.Rows[2].Timestamps[1])
+	assert.Equal(100.0, c.Rows[2].Changes[0][0].Percent)
+
+	assert.Equal(int64(600), c.Totals[0].Hits)
+	assert.Equal(int64(700), c.Totals[1].Hits)
+}
+
+func TestIntervalOffset(t *testing.T) {
+	assert := assert.New(t)
+	origin := time.Date(2014, 6, 30, 22, 30, 0, 0, time.UTC)
+
+	assert.Equal(int64(3), intervalOffset(StatsHourly, origin, time.Date(2014, 7, 1, 1, 0, 0, 0, time.UTC)))
+	assert.Equal(int64(1), intervalOffset(StatsDaily, origin, time.Date(2014, 7, 1, 1, 0, 0, 0, time.UTC)))
+	assert.Equal(int64(13), intervalOffset(StatsMonthly, origin, time.Date(2015, 7, 1, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestComparison_WriteTable(t *testing.T) {
+	assert := assert.New(t)
+
+	c := compareStats(StatsDaily, []string{"previous", "current"}, []*Stats{
+		{Summary: StatsSummary{Hits: 10}, Series: StatsSeries{{Timestamp: day(1), StatsSummary: StatsSummary{Hits: 10}}}},
+		{Summary: StatsSummary{Hits: 15}, Series: StatsSeries{{Timestamp: day(1), StatsSummary: StatsSummary{Hits: 15}}}},
+	}, func(_ int, t time.Time) int64 { return t.Unix() })
+
+	var buf bytes.Buffer
+	assert.Nil(c.WriteTable(&buf, MetricHits))
+
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	assert.Len(lines, 3)
+	assert.Equal([]string{"timestamp", "previous", "hits", "current", "hits", "current", "change", "current", "%"}, strings.Fields(lines[0]))
+	assert.Equal([]string{"2014-05-01T00:00:00Z", "10", "15", "5", "50.0%"}, strings.Fields(lines[1]))
+	assert.Equal([]string{"total", "10", "15", "5", "50.0%"}, strings.Fields(lines[2]))
+}
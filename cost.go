@@ -0,0 +1,262 @@
+package maxcdn
+
+import (
+	"sort"
+	"time"
+)
+
+// BytesPerGB is the number of bytes in a billed gigabyte.
+const BytesPerGB = 1e9
+
+// PriceTier prices the bytes of a month up to UpTo bytes, counted from the
+// start of the month. The last tier of a table should have an UpTo of zero,
+// which is unlimited.
+type PriceTier struct {
+	UpTo  int64
+	PerGB float64
+}
+
+// PriceTable is a list of tiers, by ascending UpTo.
+type PriceTable []PriceTier
+
+// Cost returns the price of a month's bytes. Bytes beyond the last bounded
+// tier are priced at the last tier.
+func (t PriceTable) Cost(bytes int64) float64 {
+	var (
+		cost  float64
+		floor int64
+	)
+	for i, tier := range t {
+		if bytes <= floor {
+			break
+		}
+
+		upTo := tier.UpTo
+		if upTo == 0 || upTo > bytes || i == len(t)-1 {
+			upTo = bytes
+		}
+		cost += float64(upTo-floor) / BytesPerGB * tier.PerGB
+		floor = upTo
+	}
+	return cost
+}
+
+// CostModel prices CDN traffic.
+type CostModel struct {
+
+	// Default prices traffic without a region.
+	Default PriceTable
+
+	// Regions prices traffic by region name, such as "north_america".
+	Regions map[string]PriceTable
+
+	// NodeRegions maps node names, such as "atl", to a key of Regions.
+	// Nodes without a region are priced with Default.
+	NodeRegions map[string]string
+}
+
+// NodeCost returns the price of the traffic in a nodes report, as a total
+// and by region. Traffic priced with Default is reported under the empty
+// region.
+func (m CostModel) NodeCost(nodes []NodeStat) (float64, map[string]float64) {
+	var (
+		total   float64
+		regions = map[string]float64{}
+	)
+	for region, b := range m.regionBytes(nodes) {
+		regions[region] = m.table(region).Cost(b)
+		total += regions[region]
+	}
+	return total, regions
+}
+
+// regionBytes sums the traffic of nodes by region, with nodes priced with
+// Default under the empty region.
+func (m CostModel) regionBytes(nodes []NodeStat) map[string]int64 {
+	bytes := map[string]int64{}
+	for _, node := range nodes {
+		region := m.NodeRegions[node.Node]
+		if _, ok := m.Regions[region]; !ok {
+			region = ""
+		}
+		bytes[region] += node.Bytes
+	}
+	return bytes
+}
+
+func (m CostModel) table(region string) PriceTable {
+	if region == "" {
+		return m.Default
+	}
+	return m.Regions[region]
+}
+
+// Extrapolation selects how Estimate projects a month's traffic.
+type Extrapolation int
+
+const (
+
+	// LinearExtrapolation projects the month-to-date traffic rate.
+	LinearExtrapolation Extrapolation = iota
+
+	// SeasonalExtrapolation projects every remaining day from the
+	// average traffic of its weekday in the series.
+	SeasonalExtrapolation
+)
+
+// CostEstimate is the cost of a month's traffic.
+type CostEstimate struct {
+	BytesToDate       int64
+	MonthToDate       float64
+	ProjectedBytes    int64
+	ProjectedMonthEnd float64
+}
+
+// Estimate prices the traffic of the month containing now, in now's
+// location, from an hourly or daily series, with the Default table. Points
+// before the month are only used by SeasonalExtrapolation, and points after
+// now are ignored. EstimateRegions prices by region instead.
+func (m CostModel) Estimate(series StatsSeries, now time.Time, method Extrapolation) CostEstimate {
+	bytes, projected := projectMonth(series, now, method)
+	return CostEstimate{
+		BytesToDate:       bytes,
+		MonthToDate:       m.Default.Cost(bytes),
+		ProjectedBytes:    projected,
+		ProjectedMonthEnd: m.Default.Cost(projected),
+	}
+}
+
+// EstimateRegions is Estimate priced by region. The month's traffic is split
+// across regions in proportion to the traffic of nodes, such as the month's
+// nodes report, and every region is priced with its table. It returns the
+// total and the estimate of every region, with traffic priced with Default
+// under the empty region. Without node traffic it prices as Estimate does.
+func (m CostModel) EstimateRegions(series StatsSeries, nodes []NodeStat, now time.Time, method Extrapolation) (CostEstimate, map[string]CostEstimate) {
+	bytes, projected := projectMonth(series, now, method)
+
+	shares := m.regionBytes(nodes)
+	var nodeBytes int64
+	for _, b := range shares {
+		nodeBytes += b
+	}
+	if nodeBytes <= 0 {
+		shares, nodeBytes = map[string]int64{"": 1}, 1
+	}
+
+	var (
+		total   = CostEstimate{BytesToDate: bytes, ProjectedBytes: projected}
+		regions = make(map[string]CostEstimate, len(shares))
+	)
+	for region, b := range shares {
+		share := ratio(b, nodeBytes)
+		e := CostEstimate{
+			BytesToDate:    int64(float64(bytes) * share),
+			ProjectedBytes: int64(float64(projected) * share),
+		}
+		e.MonthToDate = m.table(region).Cost(e.BytesToDate)
+		e.ProjectedMonthEnd = m.table(region).Cost(e.ProjectedBytes)
+		total.MonthToDate += e.MonthToDate
+		total.ProjectedMonthEnd += e.ProjectedMonthEnd
+		regions[region] = e
+	}
+	return total, regions
+}
+
+// EstimateZones estimates the cost of several zones with the Default table,
+// as Estimate does. The tiers apply to the account's total traffic, which
+// is returned with the zones' share of its cost, in proportion to their
+// bytes.
+func (m CostModel) EstimateZones(zones map[int]StatsSeries, now time.Time, method Extrapolation) (CostEstimate, map[int]CostEstimate) {
+	var (
+		total     CostEstimate
+		estimates = make(map[int]CostEstimate, len(zones))
+	)
+	for zone, series := range zones {
+		bytes, projected := projectMonth(series, now, method)
+		estimates[zone] = CostEstimate{BytesToDate: bytes, ProjectedBytes: projected}
+		total.BytesToDate += bytes
+		total.ProjectedBytes += projected
+	}
+
+	total.MonthToDate = m.Default.Cost(total.BytesToDate)
+	total.ProjectedMonthEnd = m.Default.Cost(total.ProjectedBytes)
+
+	for zone, e := range estimates {
+		e.MonthToDate = total.MonthToDate * ratio(e.BytesToDate, total.BytesToDate)
+		e.ProjectedMonthEnd = total.ProjectedMonthEnd * ratio(e.ProjectedBytes, total.ProjectedBytes)
+		estimates[zone] = e
+	}
+	return total, estimates
+}
+
+// projectMonth returns the month-to-date and projected month-end bytes.
+func projectMonth(series StatsSeries, now time.Time, method Extrapolation) (int64, int64) {
+	var (
+		start = StatsMonthly.Truncate(now)
+		end   = start.AddDate(0, 1, 0)
+		today = StatsDaily.Truncate(now)
+		bytes int64
+		days  = map[int64]int64{}
+	)
+
+	for _, p := range series {
+		if p.Timestamp.After(now) {
+			continue
+		}
+		day := StatsDaily.Truncate(p.Timestamp.In(now.Location()))
+		days[day.Unix()] += p.Bytes
+		if !p.Timestamp.Before(start) {
+			bytes += p.Bytes
+		}
+	}
+
+	if method == LinearExtrapolation {
+		elapsed := now.Sub(start)
+		if elapsed <= 0 {
+			return bytes, bytes
+		}
+		return bytes, int64(float64(bytes) * float64(end.Sub(start)) / float64(elapsed))
+	}
+
+	// Average the complete days of the series by weekday.
+	var (
+		sums   [7]int64
+		counts [7]int64
+		keys   []int64
+	)
+	for key := range days {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	var all, n int64
+	for _, key := range keys {
+		day := time.Unix(key, 0).In(now.Location())
+		if !day.Before(today) {
+			continue
+		}
+		sums[day.Weekday()] += days[key]
+		counts[day.Weekday()]++
+		all += days[key]
+		n++
+	}
+	if n == 0 {
+		return projectMonth(series, now, LinearExtrapolation)
+	}
+
+	avg := func(wd time.Weekday) int64 {
+		if counts[wd] == 0 {
+			return all / n
+		}
+		return sums[wd] / counts[wd]
+	}
+
+	projected := bytes
+	if rest := avg(today.Weekday()) - days[today.Unix()]; rest > 0 {
+		projected += rest
+	}
+	for day := today.AddDate(0, 0, 1); day.Before(end); day = day.AddDate(0, 0, 1) {
+		projected += avg(day.Weekday())
+	}
+	return bytes, projected
+}
@@ -0,0 +1,163 @@
+package maxcdn
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testPrices = PriceTable{
+	{UpTo: 10 * BytesPerGB, PerGB: 0.10},
+	{UpTo: 50 * BytesPerGB, PerGB: 0.05},
+	{PerGB: 0.01},
+}
+
+func gb(n float64) int64 {
+	return int64(n * BytesPerGB)
+}
+
+func TestPriceTable_Cost(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(0.0, testPrices.Cost(0))
+	assert.InDelta(0.5, testPrices.Cost(gb(5)), 1e-9)
+	assert.InDelta(1.0, testPrices.Cost(gb(10)), 1e-9)
+	assert.InDelta(1.0+2.0, testPrices.Cost(gb(50)), 1e-9)
+	assert.InDelta(1.0+2.0+0.5, testPrices.Cost(gb(100)), 1e-9)
+
+	// bounded last tier
+	flat := PriceTable{{UpTo: gb(1), PerGB: 1}}
+	assert.InDelta(3.0, flat.Cost(gb(3)), 1e-9)
+}
+
+func TestCostModel_NodeCost(t *testing.T) {
+	assert := assert.New(t)
+
+	m := CostModel{
+		Default:     PriceTable{{PerGB: 0.10}},
+		Regions:     map[string]PriceTable{"eu": {{PerGB: 0.20}}},
+		NodeRegions: map[string]string{"ams": "eu", "lhr": "eu", "atl": "na"},
+	}
+
+	total, regions := m.NodeCost([]NodeStat{
+		{Node: "ams", StatsSummary: StatsSummary{Bytes: gb(1)}},
+		{Node: "lhr", StatsSummary: StatsSummary{Bytes: gb(1)}},
+		{Node: "atl", StatsSummary: StatsSummary{Bytes: gb(10)}},
+	})
+	assert.InDelta(1.4, total, 1e-9)
+	assert.InDelta(0.4, regions["eu"], 1e-9)
+	assert.InDelta(1.0, regions[""], 1e-9)
+}
+
+func dailySeries(from time.Time, bytes ...int64) StatsSeries {
+	series := make(StatsSeries, len(bytes))
+	for i, b := range bytes {
+		series[i] = StatsPoint{Timestamp: from.AddDate(0, 0, i), StatsSummary: StatsSummary{Bytes: b}}
+	}
+	return series
+}
+
+func TestCostModel_Estimate_linear(t *testing.T) {
+	assert := assert.New(t)
+	m := CostModel{Default: PriceTable{{PerGB: 1}}}
+
+	// 10 days of June at 1GB a day, estimated at the end of June 10th.
+	series := dailySeries(time.Date(2014, 5, 31, 0, 0, 0, 0, time.UTC), gb(5), gb(1), gb(1), gb(1), gb(1), gb(1), gb(1), gb(1), gb(1), gb(1), gb(1))
+	now := time.Date(2014, 6, 11, 0, 0, 0, 0, time.UTC)
+
+	e := m.Estimate(series, now, LinearExtrapolation)
+	assert.Equal(gb(10), e.BytesToDate)
+	assert.InDelta(10.0, e.MonthToDate, 1e-9)
+	assert.Equal(gb(30), e.ProjectedBytes)
+	assert.InDelta(30.0, e.ProjectedMonthEnd, 1e-9)
+}
+
+func TestCostModel_Estimate_seasonal(t *testing.T) {
+	assert := assert.New(t)
+	m := CostModel{Default: PriceTable{{PerGB: 1}}}
+
+	// Two weeks of history, weekends at 3GB and weekdays at 1GB. June
+	// 1st 2014 is a Sunday.
+	var bytes []int64
+	for d := 0; d < 14; d++ {
+		wd := time.Date(2014, 5, 18+d, 0, 0, 0, 0, time.UTC).Weekday()
+		if wd == time.Saturday || wd == time.Sunday {
+			bytes = append(bytes, gb(3))
+		} else {
+			bytes = append(bytes, gb(1))
+		}
+	}
+	series := dailySeries(time.Date(2014, 5, 18, 0, 0, 0, 0, time.UTC), bytes...)
+
+	// Half of Sunday June 1st is in.
+	series = append(series, StatsPoint{
+		Timestamp:    time.Date(2014, 6, 1, 0, 0, 0, 0, time.UTC),
+		StatsSummary: StatsSummary{Bytes: gb(1)},
+	})
+	now := time.Date(2014, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	e := m.Estimate(series, now, SeasonalExtrapolation)
+	assert.Equal(gb(1), e.BytesToDate)
+
+	// June 2014 has 9 weekend days and 21 weekdays.
+	assert.Equal(gb(9*3+21), e.ProjectedBytes)
+
+	// without history, fall back to linear
+	e = m.Estimate(series[len(series)-1:], now, SeasonalExtrapolation)
+	assert.Equal(gb(60), e.ProjectedBytes)
+}
+
+func TestCostModel_EstimateRegions(t *testing.T) {
+	assert := assert.New(t)
+	m := CostModel{
+		Default:     PriceTable{{PerGB: 1}},
+		Regions:     map[string]PriceTable{"eu": {{PerGB: 2}}},
+		NodeRegions: map[string]string{"ams": "eu", "lhr": "eu"},
+	}
+
+	// 10 days of June at 1GB a day, a fifth of it served from Europe.
+	series := dailySeries(time.Date(2014, 6, 1, 0, 0, 0, 0, time.UTC), gb(1), gb(1), gb(1), gb(1), gb(1), gb(1), gb(1), gb(1), gb(1), gb(1))
+	now := time.Date(2014, 6, 11, 0, 0, 0, 0, time.UTC)
+	nodes := []NodeStat{
+		{Node: "ams", StatsSummary: StatsSummary{Bytes: gb(1)}},
+		{Node: "lhr", StatsSummary: StatsSummary{Bytes: gb(1)}},
+		{Node: "atl", StatsSummary: StatsSummary{Bytes: gb(8)}},
+	}
+
+	total, regions := m.EstimateRegions(series, nodes, now, LinearExtrapolation)
+	assert.Equal(gb(10), total.BytesToDate)
+	assert.Equal(gb(30), total.ProjectedBytes)
+	assert.InDelta(2*2+8*1, total.MonthToDate, 1e-9)
+	assert.InDelta(6*2+24*1, total.ProjectedMonthEnd, 1e-9)
+	assert.Equal(gb(2), regions["eu"].BytesToDate)
+	assert.InDelta(12.0, regions["eu"].ProjectedMonthEnd, 1e-9)
+	assert.Equal(gb(24), regions[""].ProjectedBytes)
+
+	// without nodes it's Estimate
+	total, regions = m.EstimateRegions(series, nil, now, LinearExtrapolation)
+	assert.Equal(m.Estimate(series, now, LinearExtrapolation), total)
+	assert.Len(regions, 1)
+}
+
+func TestCostModel_EstimateZones(t *testing.T) {
+	assert := assert.New(t)
+	m := CostModel{Default: testPrices}
+
+	now := time.Date(2014, 6, 16, 0, 0, 0, 0, time.UTC)
+	start := time.Date(2014, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	total, zones := m.EstimateZones(map[int]StatsSeries{
+		1: {{Timestamp: start, StatsSummary: StatsSummary{Bytes: gb(15)}}},
+		2: {{Timestamp: start, StatsSummary: StatsSummary{Bytes: gb(5)}}},
+	}, now, LinearExtrapolation)
+
+	assert.Equal(gb(20), total.BytesToDate)
+	assert.InDelta(1.5, total.MonthToDate, 1e-9)
+	assert.Equal(gb(40), total.ProjectedBytes)
+	assert.InDelta(2.5, total.ProjectedMonthEnd, 1e-9)
+
+	assert.InDelta(1.125, zones[1].MonthToDate, 1e-9)
+	assert.InDelta(0.375, zones[2].MonthToDate, 1e-9)
+	assert.InDelta(0.625, zones[2].ProjectedMonthEnd, 1e-9)
+}
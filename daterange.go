@@ -0,0 +1,126 @@
+package maxcdn
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// DateRange is a range of whole days in a location, such as the time zone
+// of the account's user. The API's day boundaries follow that time zone.
+type DateRange struct {
+
+	// From and To are the first and last day of the range, inclusive. Only
+	// their date in Location matters.
+	From time.Time
+	To   time.Time
+
+	// Location defaults to UTC when nil.
+	Location *time.Location
+}
+
+// NewDateRange returns the days from from to to, in loc.
+func NewDateRange(from, to time.Time, loc *time.Location) DateRange {
+	r := DateRange{Location: loc}
+	r.From = StatsDaily.Truncate(from.In(r.location()))
+	r.To = StatsDaily.Truncate(to.In(r.location()))
+	return r
+}
+
+// LastNDays returns the n days up to and including the day of now, in
+// now's location. n is at least 1.
+func LastNDays(n int, now time.Time) DateRange {
+	if n < 1 {
+		n = 1
+	}
+	today := StatsDaily.Truncate(now)
+	return DateRange{From: today.AddDate(0, 0, 1-n), To: today, Location: now.Location()}
+}
+
+// MonthToDate returns the days of now's month up to and including the day
+// of now, in now's location.
+func MonthToDate(now time.Time) DateRange {
+	return DateRange{From: StatsMonthly.Truncate(now), To: StatsDaily.Truncate(now), Location: now.Location()}
+}
+
+// PreviousMonth returns the days of the month before now's, in now's
+// location.
+func PreviousMonth(now time.Time) DateRange {
+	start := StatsMonthly.Truncate(now)
+	return DateRange{From: start.AddDate(0, -1, 0), To: start.AddDate(0, 0, -1), Location: now.Location()}
+}
+
+func (r DateRange) location() *time.Location {
+	if r.Location == nil {
+		return time.UTC
+	}
+	return r.Location
+}
+
+// Start returns the first instant of the range.
+func (r DateRange) Start() time.Time {
+	return StatsDaily.Truncate(r.From.In(r.location()))
+}
+
+// End returns the first instant after the range.
+func (r DateRange) End() time.Time {
+	return StatsDaily.Truncate(r.To.In(r.location())).AddDate(0, 0, 1)
+}
+
+// Days returns the number of days in the range.
+func (r DateRange) Days() int {
+	return int(intervalOffset(StatsDaily, r.Start(), r.End()))
+}
+
+// Contains reports whether t falls within the range.
+func (r DateRange) Contains(t time.Time) bool {
+	return !t.Before(r.Start()) && t.Before(r.End())
+}
+
+// Previous returns the range of the same number of days ending the day
+// before r, such as last week for this week.
+func (r DateRange) Previous() DateRange {
+	days := r.Days()
+	start := r.Start()
+	return DateRange{From: start.AddDate(0, 0, -days), To: start.AddDate(0, 0, -1), Location: r.Location}
+}
+
+// String formats the range as "2006-01-02..2006-01-02".
+func (r DateRange) String() string {
+	return r.Start().Format("2006-01-02") + ".." + r.End().AddDate(0, 0, -1).Format("2006-01-02")
+}
+
+// WithRange returns a copy of q limited to the days of r, encoded and
+// parsed in r's location.
+func (q StatsQuery) WithRange(r DateRange) StatsQuery {
+	q.From = r.Start()
+	q.To = r.End().AddDate(0, 0, -1)
+	q.Location = r.location()
+	return q
+}
+
+// UserLocation returns the time zone of a user, UTC when it isn't set.
+func (max *MaxCDN) UserLocation(user int) (*time.Location, error) {
+	rsp, err := max.Do("GET", fmt.Sprintf("/users.json/%d", user), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var data struct {
+		User struct {
+			Timezone string `json:"timezone"`
+		} `json:"user"`
+	}
+	if err := json.Unmarshal(rsp.Data, &data); err != nil {
+		return nil, err
+	}
+
+	if data.User.Timezone == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(data.User.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("maxcdn: unknown user timezone %q", data.User.Timezone)
+	}
+	return loc, nil
+}
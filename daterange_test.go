@@ -0,0 +1,99 @@
+package maxcdn
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDateRange_helpers(t *testing.T) {
+	assert := assert.New(t)
+
+	london, err := time.LoadLocation("Europe/London")
+	assert.Nil(err)
+
+	// 00:30 on March 1st in London is still February in UTC.
+	now := time.Date(2014, 3, 1, 0, 30, 0, 0, london)
+
+	r := LastNDays(7, now)
+	assert.Equal("2014-02-23..2014-03-01", r.String())
+	assert.Equal(7, r.Days())
+	assert.Equal(london, r.Start().Location())
+
+	for _, n := range []int{1, 0, -3} {
+		assert.Equal("2014-03-01..2014-03-01", LastNDays(n, now).String(), n)
+		assert.Equal(1, LastNDays(n, now).Days(), n)
+	}
+
+	assert.Equal("2014-03-01..2014-03-01", MonthToDate(now).String())
+	assert.Equal("2014-02-01..2014-02-28", PreviousMonth(now).String())
+	assert.Equal("2014-02-16..2014-02-22", r.Previous().String())
+
+	// spans the switch to summer time
+	march := PreviousMonth(time.Date(2014, 4, 10, 0, 0, 0, 0, london))
+	assert.Equal(31, march.Days())
+	assert.Equal(time.Date(2014, 4, 1, 0, 0, 0, 0, london), march.End())
+
+	assert.True(r.Contains(time.Date(2014, 2, 23, 0, 0, 0, 0, time.UTC)))
+	assert.False(r.Contains(time.Date(2014, 3, 2, 0, 0, 0, 0, time.UTC)))
+
+	utc := NewDateRange(time.Date(2014, 5, 18, 23, 0, 0, 0, london), time.Date(2014, 5, 20, 0, 0, 0, 0, london), nil)
+	assert.Equal("2014-05-18..2014-05-19", utc.String())
+}
+
+func TestStatsQuery_WithRange(t *testing.T) {
+	assert := assert.New(t)
+
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	assert.Nil(err)
+
+	r := MonthToDate(time.Date(2014, 6, 10, 8, 0, 0, 0, tokyo))
+	q := StatsQuery{Interval: StatsDaily}.WithRange(r)
+	assert.Equal("date_from=2014-06-01&date_to=2014-06-10", q.Values().Encode())
+
+	// From and To are converted to Location
+	q.From = time.Date(2014, 5, 31, 20, 0, 0, 0, time.UTC)
+	assert.Equal("2014-06-01", q.Values().Get("date_from"))
+}
+
+func TestMaxCDN_GetStats_location(t *testing.T) {
+	assert := assert.New(t)
+	max := NewMaxCDN("alias", "token", "secret")
+	max.HTTPClient = stubHTTPOk()
+
+	london, err := time.LoadLocation("Europe/London")
+	assert.Nil(err)
+
+	stats, err := max.GetStats(StatsQuery{Zone: 164197, Interval: StatsDaily, Location: london})
+	assert.Nil(err)
+	assert.Equal(time.Date(2014, 5, 18, 0, 0, 0, 0, london), stats.Series[0].Timestamp)
+	assert.Equal(london, stats.Series[0].Timestamp.Location())
+
+	ts, err := parseReportTime("2014-07-01T16:25:51Z", london)
+	assert.Nil(err)
+	assert.Equal("2014-07-01T17:25:51+01:00", ts.Format(time.RFC3339))
+}
+
+func TestMaxCDN_UserLocation(t *testing.T) {
+	assert := assert.New(t)
+	max := NewMaxCDN("alias", "token", "secret")
+
+	var path string
+	max.HTTPClient = stubHTTPFunc(func(r *http.Request) (*http.Response, error) {
+		path = r.URL.Path
+		return stubJSONResponse(r, 200, "user.json"), nil
+	})
+
+	loc, err := max.UserLocation(46753)
+	assert.Nil(err)
+	assert.Equal("Europe/London", loc.String())
+	assert.Equal("/alias/users.json/46753", path)
+
+	max.HTTPClient = stubHTTPFunc(func(r *http.Request) (*http.Response, error) {
+		return stubBodyResponse(r, 200, `{"code":200,"data":{"user":{"timezone":"Nowhere/Special"}}}`), nil
+	})
+	_, err = max.UserLocation(1)
+	assert.NotNil(err)
+}
@@ -9,6 +9,7 @@ import (
 	"io/ioutil"
 	"net/url"
 	"os"
+	"time"
 )
 
 var (
@@ -212,6 +213,31 @@ func ExampleMaxCDN_purgeFiles() {
 	}
 }
 
+func ExampleMaxCDN_purgeDirectory() {
+	max := NewMaxCDN(alias, token, secret)
+
+	res, err := max.PurgeDirectory(123456, "/static/v2/")
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Printf("Purged %d path(s)\n", len(res.Files))
+}
+
+func ExampleMaxCDN_purgePattern() {
+	max := NewMaxCDN(alias, token, secret)
+
+	// List what would be purged without purging it.
+	res, err := max.PurgePattern(123456, "/static/**.css", PurgeOptions{DryRun: true})
+	if err != nil {
+		panic(err)
+	}
+
+	for _, file := range res.Files {
+		fmt.Println(file)
+	}
+}
+
 func ExampleMaxCDN_post() {
 	max := NewMaxCDN(alias, token, secret)
 
@@ -273,17 +299,16 @@ func Example_accountAddress() {
 func Example_popularFiles() {
 	max := NewMaxCDN(alias, token, secret)
 
-	var data Generic
-	if _, err := max.Get(&data, "/reports/popularfiles.json", nil); err == nil {
-		for i, file := range data {
-			uri := file.(map[string]interface{})["uri"].(string)
-			hit := file.(map[string]interface{})["hit"].(string)
-			fmt.Printf("%2s: %30s=%s, \n", i, uri, hit)
-		}
+	report, err := max.PopularFiles(PopularFilesQuery{Top: 10})
+	if err != nil {
+		panic(err)
+	}
+
+	for i, file := range report.Files {
+		fmt.Printf("%2d: %30s=%d, \n", i, file.URI, file.Hits)
 	}
 	fmt.Println("----")
-	summaryHit := data["summary"].(map[string]interface{})["uri"].(string)
-	fmt.Printf("    %30s=%s, \n", "summary", summaryHit)
+	fmt.Printf("    %30s=%d, \n", "summary", report.Summary.Hits)
 }
 
 func Example_statsSummary() {
@@ -304,6 +329,24 @@ func Example_stats() {
 	}
 }
 
+func ExampleMaxCDN_getStats() {
+	max := NewMaxCDN(alias, token, secret)
+
+	stats, err := max.GetStats(StatsQuery{
+		Zone:     123456,
+		Interval: StatsDaily,
+		From:     time.Now().AddDate(0, 0, -7),
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	for _, point := range stats.Series {
+		fmt.Printf("%s: %d hits, %d bytes\n", point.Timestamp.Format("2006-01-02"), point.Hits, point.Bytes)
+	}
+	fmt.Printf("total: %d hits, %d bytes\n", stats.Summary.Hits, stats.Summary.Bytes)
+}
+
 // These "Functional" examples are meant to be functional integration tests.
 // To run these as integration tests export your ALIAS, TOKEN and SECRET
 // to your envioronment before running 'go test', otherwise the http
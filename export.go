@@ -0,0 +1,338 @@
+package maxcdn
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ExportTags identify the source of exported reports. They're written as
+// columns in CSV and NDJSON, and as tags in line protocol. Empty tags are
+// left out.
+type ExportTags struct {
+	Alias string
+	Zone  int
+}
+
+// CSVOptions configures the CSV exporters.
+type CSVOptions struct {
+
+	// Columns selects and orders the columns by name. When empty, every
+	// column of the report is written.
+	Columns []string
+
+	// Header replaces the column names in the header row.
+	Header []string
+
+	// NoHeader leaves out the header row.
+	NoHeader bool
+}
+
+// Line protocol measurement names.
+const (
+	StatsMeasurement        = "maxcdn_stats"
+	PopularFilesMeasurement = "maxcdn_popular_files"
+	StatusCodesMeasurement  = "maxcdn_status_codes"
+)
+
+// exportTable adapts a report to the exporters. value returns the column of
+// a row, and false for unknown columns.
+type exportTable struct {
+	columns []string
+	rows    int
+	value   func(row int, column string) (string, bool)
+}
+
+func (t exportTable) has(column string) bool {
+	for _, c := range t.columns {
+		if c == column {
+			return true
+		}
+	}
+	return false
+}
+
+func (tags ExportTags) value(column string) (string, bool) {
+	switch column {
+	case "alias":
+		return tags.Alias, true
+	case "zone":
+		if tags.Zone == 0 {
+			return "", true
+		}
+		return strconv.Itoa(tags.Zone), true
+	}
+	return "", false
+}
+
+func formatTimestamp(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func statsTable(series StatsSeries, tags ExportTags) exportTable {
+	return exportTable{
+		columns: []string{"timestamp", "alias", "zone", "hits", "cache_hits", "noncache_hits", "bytes"},
+		rows:    len(series),
+		value: func(row int, column string) (string, bool) {
+			p := series[row]
+			if column == "timestamp" {
+				return formatTimestamp(p.Timestamp), true
+			}
+			for _, m := range StatsMetrics {
+				if string(m) == column {
+					return strconv.FormatInt(p.Value(m), 10), true
+				}
+			}
+			return tags.value(column)
+		},
+	}
+}
+
+func popularFilesTable(files []PopularFile, tags ExportTags) exportTable {
+	return exportTable{
+		columns: []string{"alias", "zone", "uri", "hits", "bytes"},
+		rows:    len(files),
+		value: func(row int, column string) (string, bool) {
+			f := files[row]
+			switch column {
+			case "uri":
+				return f.URI, true
+			case "hits":
+				return strconv.FormatInt(f.Hits, 10), true
+			case "bytes":
+				return strconv.FormatInt(f.Bytes, 10), true
+			}
+			return tags.value(column)
+		},
+	}
+}
+
+func statusCodesTable(codes []StatusCodeStat, tags ExportTags) exportTable {
+	return exportTable{
+		columns: []string{"timestamp", "alias", "zone", "status_code", "hits"},
+		rows:    len(codes),
+		value: func(row int, column string) (string, bool) {
+			s := codes[row]
+			switch column {
+			case "timestamp":
+				return formatTimestamp(s.Timestamp), true
+			case "status_code":
+				return strconv.Itoa(s.StatusCode), true
+			case "hits":
+				return strconv.FormatInt(s.Hits, 10), true
+			}
+			return tags.value(column)
+		},
+	}
+}
+
+// WriteStatsCSV writes a stats series as CSV.
+func WriteStatsCSV(w io.Writer, series StatsSeries, tags ExportTags, opts CSVOptions) error {
+	return writeCSV(w, statsTable(series, tags), opts)
+}
+
+// WritePopularFilesCSV writes a popular files report as CSV.
+func WritePopularFilesCSV(w io.Writer, files []PopularFile, tags ExportTags, opts CSVOptions) error {
+	return writeCSV(w, popularFilesTable(files, tags), opts)
+}
+
+// WriteStatusCodesCSV writes a status codes report as CSV.
+func WriteStatusCodesCSV(w io.Writer, codes []StatusCodeStat, tags ExportTags, opts CSVOptions) error {
+	return writeCSV(w, statusCodesTable(codes, tags), opts)
+}
+
+func writeCSV(w io.Writer, t exportTable, opts CSVOptions) error {
+	columns := opts.Columns
+	if len(columns) == 0 {
+		columns = t.columns
+	}
+	for _, column := range columns {
+		if !t.has(column) {
+			return fmt.Errorf("maxcdn: unknown column %q", column)
+		}
+	}
+	if len(opts.Header) > 0 && len(opts.Header) != len(columns) {
+		return fmt.Errorf("maxcdn: %d header names for %d columns", len(opts.Header), len(columns))
+	}
+
+	cw := csv.NewWriter(w)
+	if !opts.NoHeader {
+		header := opts.Header
+		if len(header) == 0 {
+			header = columns
+		}
+		if err := cw.Write(header); err != nil {
+			return err
+		}
+	}
+
+	record := make([]string, len(columns))
+	for row := 0; row < t.rows; row++ {
+		for i, column := range columns {
+			record[i], _ = t.value(row, column)
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// exportRecord adds the export tags to a report row when encoded as JSON.
+type exportRecord struct {
+	Alias string `json:"alias,omitempty"`
+	Zone  int    `json:"zone,omitempty"`
+}
+
+// WriteStatsNDJSON writes a stats series as newline delimited JSON.
+func WriteStatsNDJSON(w io.Writer, series StatsSeries, tags ExportTags) error {
+	return writeNDJSON(w, len(series), func(i int) interface{} {
+		return struct {
+			exportRecord
+			StatsPoint
+		}{exportRecord{tags.Alias, tags.Zone}, series[i]}
+	})
+}
+
+// WritePopularFilesNDJSON writes a popular files report as newline
+// delimited JSON.
+func WritePopularFilesNDJSON(w io.Writer, files []PopularFile, tags ExportTags) error {
+	return writeNDJSON(w, len(files), func(i int) interface{} {
+		return struct {
+			exportRecord
+			PopularFile
+		}{exportRecord{tags.Alias, tags.Zone}, files[i]}
+	})
+}
+
+// WriteStatusCodesNDJSON writes a status codes report as newline delimited
+// JSON.
+func WriteStatusCodesNDJSON(w io.Writer, codes []StatusCodeStat, tags ExportTags) error {
+	return writeNDJSON(w, len(codes), func(i int) interface{} {
+		return struct {
+			exportRecord
+			StatusCodeStat
+		}{exportRecord{tags.Alias, tags.Zone}, codes[i]}
+	})
+}
+
+func writeNDJSON(w io.Writer, n int, record func(i int) interface{}) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	for i := 0; i < n; i++ {
+		if err := enc.Encode(record(i)); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// WriteStatsLineProtocol writes a stats series as InfluxDB line protocol,
+// one line per point.
+func WriteStatsLineProtocol(w io.Writer, series StatsSeries, tags ExportTags) error {
+	lp := newLineWriter(w)
+	for _, p := range series {
+		fields := make([]string, len(StatsMetrics))
+		for i, m := range StatsMetrics {
+			fields[i] = string(m) + "=" + strconv.FormatInt(p.Value(m), 10) + "i"
+		}
+		lp.line(StatsMeasurement, tags.lineTags(), fields, p.Timestamp)
+	}
+	return lp.flush()
+}
+
+// WritePopularFilesLineProtocol writes a popular files report as InfluxDB
+// line protocol, with the uri as a tag. Lines have no timestamp.
+func WritePopularFilesLineProtocol(w io.Writer, files []PopularFile, tags ExportTags) error {
+	lp := newLineWriter(w)
+	for _, f := range files {
+		lp.line(PopularFilesMeasurement, append(tags.lineTags(), "uri", f.URI), []string{
+			"hits=" + strconv.FormatInt(f.Hits, 10) + "i",
+			"bytes=" + strconv.FormatInt(f.Bytes, 10) + "i",
+		}, time.Time{})
+	}
+	return lp.flush()
+}
+
+// WriteStatusCodesLineProtocol writes a status codes report as InfluxDB
+// line protocol, with the status code as a tag.
+func WriteStatusCodesLineProtocol(w io.Writer, codes []StatusCodeStat, tags ExportTags) error {
+	lp := newLineWriter(w)
+	for _, s := range codes {
+		lp.line(StatusCodesMeasurement, append(tags.lineTags(), "status_code", strconv.Itoa(s.StatusCode)), []string{
+			"hits=" + strconv.FormatInt(s.Hits, 10) + "i",
+		}, s.Timestamp)
+	}
+	return lp.flush()
+}
+
+// lineTags returns the tags as key, value pairs.
+func (tags ExportTags) lineTags() []string {
+	var kv []string
+	if tags.Alias != "" {
+		kv = append(kv, "alias", tags.Alias)
+	}
+	if tags.Zone != 0 {
+		kv = append(kv, "zone", strconv.Itoa(tags.Zone))
+	}
+	return kv
+}
+
+var (
+	measurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `)
+	tagEscaper         = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+)
+
+type lineWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+func newLineWriter(w io.Writer) *lineWriter {
+	return &lineWriter{w: bufio.NewWriter(w)}
+}
+
+// line writes a single point. tags are key, value pairs and empty values
+// are left out, fields are already encoded.
+func (lp *lineWriter) line(measurement string, tags []string, fields []string, ts time.Time) {
+	if lp.err != nil {
+		return
+	}
+
+	buf := []byte(measurementEscaper.Replace(measurement))
+	for i := 0; i+1 < len(tags); i += 2 {
+		if tags[i+1] == "" {
+			continue
+		}
+		buf = append(buf, ',')
+		buf = append(buf, tagEscaper.Replace(tags[i])...)
+		buf = append(buf, '=')
+		buf = append(buf, tagEscaper.Replace(tags[i+1])...)
+	}
+	buf = append(buf, ' ')
+	buf = append(buf, strings.Join(fields, ",")...)
+	if !ts.IsZero() {
+		buf = append(buf, ' ')
+		buf = strconv.AppendInt(buf, ts.UnixNano(), 10)
+	}
+	buf = append(buf, '\n')
+
+	_, lp.err = lp.w.Write(buf)
+}
+
+func (lp *lineWriter) flush() error {
+	if lp.err != nil {
+		return lp.err
+	}
+	return lp.w.Flush()
+}
@@ -0,0 +1,92 @@
+package maxcdn
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var exportSeries = StatsSeries{
+	{Timestamp: day(18), StatsSummary: StatsSummary{Hits: 267, CacheHits: 122, NonCacheHits: 145, Bytes: 235724}},
+	{Timestamp: day(19), StatsSummary: StatsSummary{Hits: 461, CacheHits: 194, NonCacheHits: 267, Bytes: 414066}},
+}
+
+func TestWriteStatsCSV(t *testing.T) {
+	assert := assert.New(t)
+
+	var buf bytes.Buffer
+	err := WriteStatsCSV(&buf, exportSeries, ExportTags{Alias: "alias", Zone: 123}, CSVOptions{})
+	assert.Nil(err)
+	assert.Equal("timestamp,alias,zone,hits,cache_hits,noncache_hits,bytes\n"+
+		"2014-05-18T00:00:00Z,alias,123,267,122,145,235724\n"+
+		"2014-05-19T00:00:00Z,alias,123,461,194,267,414066\n", buf.String())
+
+	buf.Reset()
+	err = WriteStatsCSV(&buf, exportSeries, ExportTags{}, CSVOptions{
+		Columns: []string{"timestamp", "bytes", "zone"},
+		Header:  []string{"Date", "Bytes", "Zone"},
+	})
+	assert.Nil(err)
+	assert.Equal("Date,Bytes,Zone\n2014-05-18T00:00:00Z,235724,\n2014-05-19T00:00:00Z,414066,\n", buf.String())
+
+	buf.Reset()
+	err = WriteStatsCSV(&buf, exportSeries[:1], ExportTags{}, CSVOptions{Columns: []string{"hits"}, NoHeader: true})
+	assert.Nil(err)
+	assert.Equal("267\n", buf.String())
+
+	assert.NotNil(WriteStatsCSV(&buf, nil, ExportTags{}, CSVOptions{Columns: []string{"nope"}}))
+	assert.NotNil(WriteStatsCSV(&buf, nil, ExportTags{}, CSVOptions{Columns: []string{"hits"}, Header: []string{"a", "b"}}))
+}
+
+func TestWritePopularFilesCSV(t *testing.T) {
+	assert := assert.New(t)
+
+	var buf bytes.Buffer
+	files := []PopularFile{{URI: "/a,b.css", Hits: 10, Bytes: 100}}
+	assert.Nil(WritePopularFilesCSV(&buf, files, ExportTags{Zone: 1}, CSVOptions{}))
+	assert.Equal("alias,zone,uri,hits,bytes\n,1,\"/a,b.css\",10,100\n", buf.String())
+}
+
+func TestWriteStatusCodesCSV(t *testing.T) {
+	assert := assert.New(t)
+
+	var buf bytes.Buffer
+	codes := []StatusCodeStat{{StatusCode: 404, Hits: 219}}
+	assert.Nil(WriteStatusCodesCSV(&buf, codes, ExportTags{}, CSVOptions{Columns: []string{"status_code", "hits"}}))
+	assert.Equal("status_code,hits\n404,219\n", buf.String())
+}
+
+func TestWriteNDJSON(t *testing.T) {
+	assert := assert.New(t)
+
+	var buf bytes.Buffer
+	assert.Nil(WriteStatsNDJSON(&buf, exportSeries[:1], ExportTags{Alias: "alias", Zone: 123}))
+	assert.Equal(`{"alias":"alias","zone":123,"timestamp":"2014-05-18T00:00:00Z","hits":267,"cache_hits":122,"noncache_hits":145,"bytes":235724}`+"\n", buf.String())
+
+	buf.Reset()
+	assert.Nil(WritePopularFilesNDJSON(&buf, []PopularFile{{URI: "/a.css", Hits: 1}, {URI: "/b.css"}}, ExportTags{}))
+	assert.Equal(`{"uri":"/a.css","hits":1,"bytes":0}`+"\n"+`{"uri":"/b.css","hits":0,"bytes":0}`+"\n", buf.String())
+
+	buf.Reset()
+	assert.Nil(WriteStatusCodesNDJSON(&buf, []StatusCodeStat{{Timestamp: day(18), StatusCode: 200, Hits: 5}}, ExportTags{Zone: 1}))
+	assert.Equal(`{"zone":1,"timestamp":"2014-05-18T00:00:00Z","status_code":200,"hits":5}`+"\n", buf.String())
+}
+
+func TestWriteLineProtocol(t *testing.T) {
+	assert := assert.New(t)
+
+	var buf bytes.Buffer
+	assert.Nil(WriteStatsLineProtocol(&buf, exportSeries[:1], ExportTags{Alias: "my alias", Zone: 123}))
+	assert.Equal(`maxcdn_stats,alias=my\ alias,zone=123 hits=267i,cache_hits=122i,noncache_hits=145i,bytes=235724i 1400371200000000000`+"\n", buf.String())
+
+	buf.Reset()
+	assert.Nil(WritePopularFilesLineProtocol(&buf, []PopularFile{{URI: "/a=b,c.css", Hits: 10, Bytes: 100}}, ExportTags{}))
+	assert.Equal(`maxcdn_popular_files,uri=/a\=b\,c.css hits=10i,bytes=100i`+"\n", buf.String())
+
+	buf.Reset()
+	ts := time.Date(2014, 5, 18, 1, 0, 0, 0, time.UTC)
+	assert.Nil(WriteStatusCodesLineProtocol(&buf, []StatusCodeStat{{Timestamp: ts, StatusCode: 500, Hits: 3}}, ExportTags{Zone: 1}))
+	assert.Equal(`maxcdn_status_codes,zone=1,status_code=500 hits=3i 1400374800000000000`+"\n", buf.String())
+}
@@ -0,0 +1,490 @@
+package maxcdn
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// LogFilter is a compiled filter expression over log records, for filtering
+// records client-side. Expressions compare fields, named as in LogFields, to
+// literals:
+//
+//	status >= 500 and pop in ("atl", "lax") and uri ~ "^/api/"
+//
+// Numeric fields support ==, !=, <, <=, > and >=. String fields support ==
+// and !=, and ~ and !~ to match regular expressions. Both support in and
+// not in with a list of literals. Comparisons combine with and, or, not and
+// parentheses, and && || ! may be used instead of the keywords.
+//
+// Strings are in double or single quotes. A backslash escapes the quote or
+// a backslash and is otherwise kept, so uri ~ "\.css$" matches ".css".
+type LogFilter struct {
+	expr string
+	eval func(*LogRecord) bool
+}
+
+// LogFilterError reports an invalid filter expression.
+type LogFilterError struct {
+
+	// Pos is the byte offset of the error in the expression.
+	Pos int
+	Msg string
+}
+
+func (e *LogFilterError) Error() string {
+	return fmt.Sprintf("maxcdn: filter: %s at position %d", e.Msg, e.Pos)
+}
+
+// ParseLogFilter parses and type checks a filter expression.
+func ParseLogFilter(expr string) (*LogFilter, error) {
+	p := &filterParser{lex: filterLexer{src: expr}}
+	p.next()
+
+	eval, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	if p.tok.kind != tokEOF {
+		return nil, p.errorf("unexpected %s", p.tok)
+	}
+	return &LogFilter{expr: expr, eval: eval}, nil
+}
+
+// MustParseLogFilter is like ParseLogFilter but panics on invalid
+// expressions.
+func MustParseLogFilter(expr string) *LogFilter {
+	f, err := ParseLogFilter(expr)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+// Match reports whether r matches the filter. A nil filter matches every
+// record.
+func (f *LogFilter) Match(r *LogRecord) bool {
+	return f == nil || f.eval(r)
+}
+
+// String returns the source expression.
+func (f *LogFilter) String() string {
+	if f == nil {
+		return ""
+	}
+	return f.expr
+}
+
+// Field accessors of the filter, by JSON name. Integers are compared as
+// float64, which is exact for every API value.
+var (
+	filterStrings = map[string]func(*LogRecord) string{
+		"cache_status":     func(r *LogRecord) string { return r.CacheStatus },
+		"client_asn":       func(r *LogRecord) string { return r.ClientAsn },
+		"client_city":      func(r *LogRecord) string { return r.ClientCity },
+		"client_continent": func(r *LogRecord) string { return r.ClientContinent },
+		"client_country":   func(r *LogRecord) string { return r.ClientCountry },
+		"client_dma":       func(r *LogRecord) string { return r.ClientDma },
+		"client_ip":        func(r *LogRecord) string { return r.ClientIP },
+		"client_state":     func(r *LogRecord) string { return r.ClientState },
+		"hostname":         func(r *LogRecord) string { return r.Hostname },
+		"method":           func(r *LogRecord) string { return r.Method },
+		"pop":              func(r *LogRecord) string { return r.Pop },
+		"protocol":         func(r *LogRecord) string { return r.Protocol },
+		"query_string":     func(r *LogRecord) string { return r.QueryString },
+		"referer":          func(r *LogRecord) string { return r.Referer },
+		"scheme":           func(r *LogRecord) string { return r.Scheme },
+		"time":             func(r *LogRecord) string { return r.Time },
+		"uri":              func(r *LogRecord) string { return r.URI },
+		"user_agent":       func(r *LogRecord) string { return r.UserAgent },
+	}
+	filterNumbers = map[string]func(*LogRecord) float64{
+		"bytes":            func(r *LogRecord) float64 { return float64(r.Bytes) },
+		"client_latitude":  func(r *LogRecord) float64 { return r.ClientLatitude },
+		"client_longitude": func(r *LogRecord) float64 { return r.ClientLongitude },
+		"company_id":       func(r *LogRecord) float64 { return float64(r.CompanyID) },
+		"origin_time":      func(r *LogRecord) float64 { return r.OriginTime },
+		"status":           func(r *LogRecord) float64 { return float64(r.Status) },
+		"zone_id":          func(r *LogRecord) float64 { return float64(r.ZoneID) },
+	}
+)
+
+type filterTokenKind int
+
+const (
+	tokEOF filterTokenKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokOp
+	tokLParen
+	tokRParen
+	tokComma
+)
+
+type filterToken struct {
+	kind filterTokenKind
+	text string
+	pos  int
+}
+
+func (t filterToken) String() string {
+	if t.kind == tokEOF {
+		return "end of expression"
+	}
+	return strconv.Quote(t.text)
+}
+
+// is reports whether t is the keyword or operator s. Keywords are case
+// insensitive.
+func (t filterToken) is(s string) bool {
+	switch t.kind {
+	case tokIdent:
+		return strings.EqualFold(t.text, s)
+	case tokOp:
+		return t.text == s
+	}
+	return false
+}
+
+type filterLexer struct {
+	src string
+	pos int
+}
+
+func (l *filterLexer) next() (filterToken, error) {
+	for l.pos < len(l.src) && unicode.IsSpace(rune(l.src[l.pos])) {
+		l.pos++
+	}
+	start := l.pos
+	if l.pos >= len(l.src) {
+		return filterToken{kind: tokEOF, pos: start}, nil
+	}
+
+	c := l.src[l.pos]
+	switch {
+	case c == '(':
+		l.pos++
+		return filterToken{kind: tokLParen, text: "(", pos: start}, nil
+	case c == ')':
+		l.pos++
+		return filterToken{kind: tokRParen, text: ")", pos: start}, nil
+	case c == ',':
+		l.pos++
+		return filterToken{kind: tokComma, text: ",", pos: start}, nil
+
+	case c == '"' || c == '\'':
+		for l.pos++; l.pos < len(l.src) && l.src[l.pos] != c; l.pos++ {
+			if l.src[l.pos] == '\\' {
+				l.pos++
+			}
+		}
+		if l.pos >= len(l.src) {
+			return filterToken{}, &LogFilterError{Pos: start, Msg: "unterminated string"}
+		}
+		l.pos++
+
+		s := unquoteFilterString(l.src[start+1:l.pos-1], c)
+		return filterToken{kind: tokString, text: s, pos: start}, nil
+
+	case c == '-' || c == '.' || (c >= '0' && c <= '9'):
+		for l.pos++; l.pos < len(l.src) && strings.IndexByte("0123456789.eE+-", l.src[l.pos]) >= 0; l.pos++ {
+		}
+		return filterToken{kind: tokNumber, text: l.src[start:l.pos], pos: start}, nil
+
+	case c == '_' || unicode.IsLetter(rune(c)):
+		for l.pos < len(l.src) && (l.src[l.pos] == '_' || unicode.IsLetter(rune(l.src[l.pos])) || unicode.IsDigit(rune(l.src[l.pos]))) {
+			l.pos++
+		}
+		return filterToken{kind: tokIdent, text: l.src[start:l.pos], pos: start}, nil
+	}
+
+	for _, op := range []string{"==", "!=", "<=", ">=", "!~", "&&", "||", "=", "<", ">", "~", "!"} {
+		if strings.HasPrefix(l.src[l.pos:], op) {
+			l.pos += len(op)
+			if op == "=" {
+				op = "=="
+			}
+			return filterToken{kind: tokOp, text: op, pos: start}, nil
+		}
+	}
+	return filterToken{}, &LogFilterError{Pos: start, Msg: fmt.Sprintf("unexpected %q", c)}
+}
+
+// unquoteFilterString unescapes the body of a quoted string. In both quote
+// styles a backslash escapes the quote or another backslash, and is kept as
+// is before anything else, so regular expressions such as "\bfoo\.css$"
+// need no extra escaping.
+func unquoteFilterString(s string, quote byte) string {
+	buf := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && (s[i+1] == quote || s[i+1] == '\\') {
+			i++
+		}
+		buf = append(buf, s[i])
+	}
+	return string(buf)
+}
+
+// filterParser is a recursive descent parser, compiling the expression to
+// closures as it goes.
+type filterParser struct {
+	lex filterLexer
+	tok filterToken
+	err error
+}
+
+func (p *filterParser) next() {
+	if p.err != nil {
+		return
+	}
+	p.tok, p.err = p.lex.next()
+}
+
+func (p *filterParser) errorf(format string, args ...interface{}) error {
+	if p.err != nil {
+		return p.err
+	}
+	return &LogFilterError{Pos: p.tok.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *filterParser) parseOr() (func(*LogRecord) bool, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.tok.is("or") || p.tok.is("||") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(r *LogRecord) bool { return l(r) || right(r) }
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (func(*LogRecord) bool, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.tok.is("and") || p.tok.is("&&") {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(r *LogRecord) bool { return l(r) && right(r) }
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseNot() (func(*LogRecord) bool, error) {
+	if p.tok.is("not") || p.tok.is("!") {
+		p.next()
+		inner, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return func(r *LogRecord) bool { return !inner(r) }, nil
+	}
+
+	if p.tok.kind == tokLParen {
+		p.next()
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.tok.kind != tokRParen {
+			return nil, p.errorf("expected ) but found %s", p.tok)
+		}
+		p.next()
+		return inner, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *filterParser) parseComparison() (func(*LogRecord) bool, error) {
+	if p.tok.kind != tokIdent {
+		return nil, p.errorf("expected a field but found %s", p.tok)
+	}
+	field := p.tok
+	str, isString := filterStrings[field.text]
+	num, isNumber := filterNumbers[field.text]
+	if !isString && !isNumber {
+		return nil, p.errorf("unknown field %q", field.text)
+	}
+	p.next()
+
+	negate := false
+	if p.tok.is("not") {
+		negate = true
+		p.next()
+		if !p.tok.is("in") {
+			return nil, p.errorf("expected in but found %s", p.tok)
+		}
+	}
+
+	if p.tok.is("in") {
+		p.next()
+		literals, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+
+		var in func(*LogRecord) bool
+		if isString {
+			set := map[string]bool{}
+			for _, lit := range literals {
+				if lit.kind != tokString {
+					return nil, &LogFilterError{Pos: lit.pos, Msg: fmt.Sprintf("%s is a string field but %s is not a string", field.text, lit)}
+				}
+				set[lit.text] = true
+			}
+			in = func(r *LogRecord) bool { return set[str(r)] }
+		} else {
+			set := map[float64]bool{}
+			for _, lit := range literals {
+				n, err := p.number(field, lit)
+				if err != nil {
+					return nil, err
+				}
+				set[n] = true
+			}
+			in = func(r *LogRecord) bool { return set[num(r)] }
+		}
+
+		if negate {
+			return func(r *LogRecord) bool { return !in(r) }, nil
+		}
+		return in, nil
+	}
+
+	if p.tok.kind != tokOp {
+		return nil, p.errorf("expected an operator after %s but found %s", field.text, p.tok)
+	}
+	op := p.tok
+	p.next()
+	lit := p.tok
+	if lit.kind != tokString && lit.kind != tokNumber {
+		return nil, p.errorf("expected a value but found %s", lit)
+	}
+	p.next()
+
+	if isString {
+		return p.compileString(field, op, lit, str)
+	}
+	return p.compileNumber(field, op, lit, num)
+}
+
+// parseList parses a parenthesized list of literals.
+func (p *filterParser) parseList() ([]filterToken, error) {
+	if p.tok.kind != tokLParen {
+		return nil, p.errorf("expected ( but found %s", p.tok)
+	}
+	p.next()
+
+	var literals []filterToken
+	for {
+		if p.tok.kind != tokString && p.tok.kind != tokNumber {
+			return nil, p.errorf("expected a value but found %s", p.tok)
+		}
+		literals = append(literals, p.tok)
+		p.next()
+
+		if p.tok.kind == tokRParen {
+			p.next()
+			return literals, p.err
+		}
+		if p.tok.kind != tokComma {
+			return nil, p.errorf("expected , or ) but found %s", p.tok)
+		}
+		p.next()
+	}
+}
+
+func (p *filterParser) compileString(field, op, lit filterToken, str func(*LogRecord) string) (func(*LogRecord) bool, error) {
+	if lit.kind != tokString {
+		return nil, &LogFilterError{Pos: lit.pos, Msg: fmt.Sprintf("%s is a string field but %s is not a string", field.text, lit)}
+	}
+	v := lit.text
+
+	switch op.text {
+	case "==":
+		return func(r *LogRecord) bool { return str(r) == v }, nil
+	case "!=":
+		return func(r *LogRecord) bool { return str(r) != v }, nil
+	case "~", "!~":
+		re, err := regexp.Compile(v)
+		if err != nil {
+			return nil, &LogFilterError{Pos: lit.pos, Msg: "invalid regular expression: " + err.Error()}
+		}
+		if op.text == "!~" {
+			return func(r *LogRecord) bool { return !re.MatchString(str(r)) }, nil
+		}
+		return func(r *LogRecord) bool { return re.MatchString(str(r)) }, nil
+	}
+	return nil, &LogFilterError{Pos: op.pos, Msg: fmt.Sprintf("operator %s isn't supported by string field %s", op.text, field.text)}
+}
+
+func (p *filterParser) compileNumber(field, op, lit filterToken, num func(*LogRecord) float64) (func(*LogRecord) bool, error) {
+	v, err := p.number(field, lit)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.text {
+	case "==":
+		return func(r *LogRecord) bool { return num(r) == v }, nil
+	case "!=":
+		return func(r *LogRecord) bool { return num(r) != v }, nil
+	case "<":
+		return func(r *LogRecord) bool { return num(r) < v }, nil
+	case "<=":
+		return func(r *LogRecord) bool { return num(r) <= v }, nil
+	case ">":
+		return func(r *LogRecord) bool { return num(r) > v }, nil
+	case ">=":
+		return func(r *LogRecord) bool { return num(r) >= v }, nil
+	}
+	return nil, &LogFilterError{Pos: op.pos, Msg: fmt.Sprintf("operator %s isn't supported by numeric field %s", op.text, field.text)}
+}
+
+func (p *filterParser) number(field, lit filterToken) (float64, error) {
+	if lit.kind != tokNumber {
+		return 0, &LogFilterError{Pos: lit.pos, Msg: fmt.Sprintf("%s is a numeric field but %s is not a number", field.text, lit)}
+	}
+	n, err := strconv.ParseFloat(lit.text, 64)
+	if err != nil {
+		return 0, &LogFilterError{Pos: lit.pos, Msg: "invalid number " + lit.text}
+	}
+	return n, nil
+}
+
+// filterLogWriter is a LogWriter dropping records which don't match a
+// filter.
+type filterLogWriter struct {
+	LogWriter
+	filter *LogFilter
+}
+
+// FilterLogWriter returns a LogWriter which only writes the records
+// matching f to w.
+func FilterLogWriter(w LogWriter, f *LogFilter) LogWriter {
+	return &filterLogWriter{LogWriter: w, filter: f}
+}
+
+func (w *filterLogWriter) WriteRecord(r LogRecord) error {
+	if !w.filter.Match(&r) {
+		return nil
+	}
+	return w.LogWriter.WriteRecord(r)
+}
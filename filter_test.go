@@ -0,0 +1,162 @@
+package maxcdn
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLogFilter(t *testing.T) {
+	assert := assert.New(t)
+
+	r := LogRecord{
+		Status:      503,
+		Pop:         "lax",
+		URI:         "/api/users",
+		CacheStatus: "MISS",
+		OriginTime:  1.5,
+		UserAgent:   `curl "7.0"`,
+	}
+
+	for expr, want := range map[string]bool{
+		`status >= 500 and pop in ("atl","lax") and uri ~ "^/api/"`:  true,
+		`status >= 500 and pop in ("atl") and uri ~ "^/api/"`:        false,
+		`status < 500 or (cache_status = 'MISS' && origin_time > 1)`: true,
+		`not status == 503`:                                     false,
+		`!(status != 503)`:                                      true,
+		`pop not in ("atl", "dal")`:                             true,
+		`status in (200, 304)`:                                  false,
+		`uri !~ "\\.css$" AND method == ""`:                     true,
+		`user_agent == "curl \"7.0\""`:                          true,
+		`user_agent == 'curl "7.0"'`:                            true,
+		`bytes == 0 and origin_time >= 1.5e0 and zone_id != -1`: true,
+	} {
+		f, err := ParseLogFilter(expr)
+		if err != nil {
+			t.Errorf("%s: %v", expr, err)
+			continue
+		}
+		assert.Equal(want, f.Match(&r), expr)
+		assert.Equal(expr, f.String())
+	}
+
+	// backslashes of regular expressions
+	r.URI = "/css/site.min.css"
+	for expr, want := range map[string]bool{
+		`uri ~ "\.css$"`:               true,
+		`uri ~ '\.css$'`:               true,
+		`uri ~ "\\.css$"`:              true,
+		`uri ~ '\.min\.css$'`:          true,
+		`uri ~ "^/\w+/site\.css$"`:     false,
+		`uri ~ "\bsite\b"`:             true,
+		`uri ~ '\bsite\b'`:             true,
+		`uri ~ "\bmin\.c\b"`:           false,
+		`uri ~ "\d"`:                   false,
+		`uri ~ '[^\d]+'`:               true,
+		`uri ~ "\\\\" or uri ~ '\\\\'`: false,
+		`uri == '/css/site.min.css'`:   true,
+		`uri !~ 'it\'s'`:               true,
+		`user_agent == "curl \"7.0\""`: true,
+		`user_agent == 'curl "7.0"'`:   true,
+		`user_agent == 'curl \"7.0\"'`: false,
+	} {
+		assert.Equal(want, MustParseLogFilter(expr).Match(&r), expr)
+	}
+	r.URI = "/api/foo bar"
+	assert.True(MustParseLogFilter(`uri ~ "\bfoo\b"`).Match(&r))
+	assert.True(MustParseLogFilter(`uri ~ '\bfoo\b'`).Match(&r))
+	assert.False(MustParseLogFilter(`uri ~ "\d"`).Match(&r))
+	r.URI = "/api/users"
+
+	// and binds tighter than or
+	f := MustParseLogFilter(`status == 503 or status == 200 and pop == "atl"`)
+	assert.True(f.Match(&r))
+
+	var none *LogFilter
+	assert.True(none.Match(&r))
+}
+
+func TestParseLogFilter_errors(t *testing.T) {
+	assert := assert.New(t)
+
+	for expr, pos := range map[string]int{
+		``:                           0,
+		`status`:                     6,
+		`status >=`:                  9,
+		`bogus == 1`:                 0,
+		`status == "500"`:            10,
+		`pop == 1`:                   7,
+		`pop > "atl"`:                4,
+		`status ~ "5.."`:             9,
+		`uri ~ "("`:                  6,
+		`pop in "atl"`:               7,
+		`pop in ("atl" "lax")`:       14,
+		`status in ("500")`:          11,
+		`(status == 500`:             14,
+		`status == 500 pop == "atl"`: 14,
+		`status == 500 @`:            14,
+		`uri == "open`:               7,
+		`status == 5x`:               11,
+		`pop not ("atl")`:            8,
+	} {
+		_, err := ParseLogFilter(expr)
+		if !assert.NotNil(err, expr) {
+			continue
+		}
+		ferr, ok := err.(*LogFilterError)
+		assert.True(ok, expr)
+		assert.Equal(pos, ferr.Pos, "%s: %v", expr, err)
+		assert.True(strings.HasPrefix(err.Error(), "maxcdn: filter: "))
+	}
+
+	assert.Panics(func() { MustParseLogFilter("bogus") })
+}
+
+func TestLogFilter_fields(t *testing.T) {
+	assert := assert.New(t)
+
+	var fields []string
+	for _, name := range LogFields {
+		_, isString := filterStrings[name]
+		_, isNumber := filterNumbers[name]
+		if isString || isNumber {
+			fields = append(fields, name)
+		}
+	}
+	assert.Equal(LogFields, fields)
+	assert.Equal(len(LogFields), len(filterStrings)+len(filterNumbers))
+}
+
+func TestLogIterator_filter(t *testing.T) {
+	assert := assert.New(t)
+	max := NewMaxCDN("alias", "token", "secret")
+	max.HTTPClient = stubHTTPOk()
+
+	it := max.IterateLogs(LogsQuery{Filter: MustParseLogFilter(`cache_status == "EXPIRED" and pop in ("dal", "lhr")`)})
+	var n int
+	for it.Next() {
+		assert.Equal("EXPIRED", it.Record().CacheStatus)
+		n++
+	}
+	assert.Nil(it.Err())
+	assert.True(n > 0 && n < 8)
+
+	var buf bytes.Buffer
+	w := FilterLogWriter(NewNDJSONLogWriter(&buf), MustParseLogFilter(`status >= 400`))
+	assert.Nil(w.WriteRecord(LogRecord{Status: 200}))
+	assert.Nil(w.WriteRecord(LogRecord{Status: 404}))
+	assert.Nil(w.Flush())
+	assert.Equal(1, strings.Count(buf.String(), "\n"))
+}
+
+func BenchmarkLogFilter(b *testing.B) {
+	f := MustParseLogFilter(`status >= 500 and pop in ("atl","lax") and uri ~ "^/api/"`)
+	r := LogRecord{Status: 503, Pop: "lax", URI: "/api/users"}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		f.Match(&r)
+	}
+}
@@ -99,3 +99,33 @@ func stubHTTPErrRecorded(recorder *http.Response) *http.Client {
 		},
 	}
 }
+
+// stubRoundTripFunc adapts a function into an http.RoundTripper, for tests
+// that need to answer individual requests differently.
+type stubRoundTripFunc func(r *http.Request) (*http.Response, error)
+
+func (fn stubRoundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return fn(r)
+}
+
+func stubHTTPFunc(fn stubRoundTripFunc) *http.Client {
+	return &http.Client{Transport: fn}
+}
+
+// stubJSONResponse builds a response for stubRoundTripFunc from a fixture.
+func stubJSONResponse(r *http.Request, code int, filename string) *http.Response {
+	return stubBodyResponse(r, code, string(fetchJSON(filename)))
+}
+
+// stubBodyResponse builds a response for stubRoundTripFunc from a raw body.
+func stubBodyResponse(r *http.Request, code int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: code,
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+		Request:    r,
+	}
+}
+
+// stubAPIError is an API error body, which unlike _fixtures/error.json
+// carries a code.
+const stubAPIError = `{"code":400,"error":{"type":"Test Error","message":"Test Error Message"}}`
@@ -0,0 +1,183 @@
+package maxcdn
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Journal operations.
+const (
+	JournalPurgeZone      = "purge_zone"
+	JournalPurgeFile      = "purge_file"
+	JournalPurgeDirectory = "purge_directory"
+)
+
+// JournalEntry is a single purge call recorded by a Journal.
+type JournalEntry struct {
+	Time   time.Time `json:"time"`
+	Alias  string    `json:"alias"`
+	Op     string    `json:"op"`
+	Zone   string    `json:"zone"`
+	Files  []string  `json:"files,omitempty"`
+	Code   int       `json:"code"`
+	Error  string    `json:"error,omitempty"`
+	Actor  string    `json:"actor,omitempty"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+// Journal appends a JSON Lines record for every purge made through a
+// MaxCDN client it's set on. Bulk purges are recorded one call at a time.
+//
+// A Journal is safe for concurrent use.
+type Journal struct {
+	mu  sync.Mutex
+	w   io.Writer
+	err error
+}
+
+// NewJournal sets up a new Journal writing to w.
+func NewJournal(w io.Writer) *Journal {
+	return &Journal{w: w}
+}
+
+// OpenJournal sets up a new Journal appending to a file, which is created
+// when missing.
+func OpenJournal(filename string) (*Journal, error) {
+	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return NewJournal(f), nil
+}
+
+// Record appends an entry to the journal.
+func (j *Journal) Record(e JournalEntry) error {
+	buf, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if _, err = j.w.Write(append(buf, '\n')); err != nil && j.err == nil {
+		j.err = err
+	}
+	return err
+}
+
+// Err returns the first error hit while recording purges, which is not
+// reported by the purge methods themselves.
+func (j *Journal) Err() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.err
+}
+
+// Close closes the underlying writer when it's an io.Closer.
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if c, ok := j.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// JournalQuery selects journal entries. Empty fields match everything, and
+// From and To are inclusive.
+type JournalQuery struct {
+	Zone string
+	From time.Time
+	To   time.Time
+}
+
+// Match reports whether e is selected by q.
+func (q JournalQuery) Match(e JournalEntry) bool {
+	switch {
+	case q.Zone != "" && q.Zone != e.Zone:
+		return false
+	case !q.From.IsZero() && e.Time.Before(q.From):
+		return false
+	case !q.To.IsZero() && e.Time.After(q.To):
+		return false
+	}
+	return true
+}
+
+// ReadJournal returns the entries from a JSON Lines journal matching q.
+func ReadJournal(r io.Reader, q JournalQuery) ([]JournalEntry, error) {
+	var (
+		entries []JournalEntry
+		scanner = bufio.NewScanner(r)
+	)
+	scanner.Buffer(nil, 1<<24)
+
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var e JournalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return entries, err
+		}
+		if q.Match(e) {
+			entries = append(entries, e)
+		}
+	}
+	return entries, scanner.Err()
+}
+
+// QueryJournal returns the entries from a journal file matching q.
+func QueryJournal(filename string, q JournalQuery) ([]JournalEntry, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ReadJournal(f, q)
+}
+
+// WithActor returns a shallow copy of max which records actor and reason
+// with every purge in its Journal.
+func (max *MaxCDN) WithActor(actor, reason string) *MaxCDN {
+	clone := *max
+	clone.actor = actor
+	clone.reason = reason
+	return &clone
+}
+
+// journal records a purge call when a Journal is set.
+func (max *MaxCDN) journal(op, zone string, files []string, rsp *Response, err error) {
+	if max.Journal == nil {
+		return
+	}
+
+	e := JournalEntry{
+		Time:   time.Now().UTC(),
+		Alias:  max.Alias,
+		Op:     op,
+		Zone:   zone,
+		Files:  files,
+		Actor:  max.actor,
+		Reason: max.reason,
+	}
+	if rsp != nil {
+		e.Code = rsp.Code
+	}
+	if err != nil {
+		e.Error = err.Error()
+		if apiErr, ok := err.(*APIError); ok {
+			e.Code = apiErr.Code
+		}
+	}
+
+	_ = max.Journal.Record(e)
+}
@@ -0,0 +1,127 @@
+package maxcdn
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaxCDN_Journal(t *testing.T) {
+	assert := assert.New(t)
+	max := NewMaxCDN("alias", "token", "secret")
+	max.HTTPClient = stubHTTPFunc(func(r *http.Request) (*http.Response, error) {
+		return stubJSONResponse(r, 200, "delete.json"), nil
+	})
+
+	var buf bytes.Buffer
+	max.Journal = NewJournal(&buf)
+
+	deploy := max.WithActor("ci", "release 1.2")
+	_, err := deploy.PurgeFiles(123456, []string{"/master.css", "/master.js"})
+	assert.Nil(err)
+	_, err = max.PurgeZone(234567)
+	assert.Nil(err)
+	_, err = max.PurgeZonesString([]string{"345678"})
+	assert.Nil(err)
+	assert.Nil(max.Journal.Err())
+
+	// WithActor doesn't change the original client.
+	assert.Equal("", max.actor)
+
+	entries, err := ReadJournal(&buf, JournalQuery{})
+	assert.Nil(err)
+	assert.Len(entries, 4)
+
+	for _, e := range entries {
+		assert.Equal("alias", e.Alias)
+		assert.Equal(200, e.Code)
+		assert.Equal("", e.Error)
+		assert.WithinDuration(time.Now(), e.Time, time.Minute)
+	}
+
+	assert.Equal(JournalPurgeFile, entries[0].Op)
+	assert.Equal("123456", entries[0].Zone)
+	assert.Equal("ci", entries[0].Actor)
+	assert.Equal("release 1.2", entries[0].Reason)
+	assert.ElementsMatch([]string{"/master.css", "/master.js"}, append(entries[0].Files, entries[1].Files...))
+
+	assert.Equal(JournalPurgeZone, entries[2].Op)
+	assert.Equal("234567", entries[2].Zone)
+	assert.Empty(entries[2].Files)
+	assert.Equal("", entries[2].Actor)
+}
+
+func TestMaxCDN_Journal_error(t *testing.T) {
+	assert := assert.New(t)
+	max := NewMaxCDN("alias", "token", "secret")
+	max.HTTPClient = stubHTTPFunc(func(r *http.Request) (*http.Response, error) {
+		return stubBodyResponse(r, 400, stubAPIError), nil
+	})
+
+	var buf bytes.Buffer
+	max.Journal = NewJournal(&buf)
+
+	_, err := max.PurgeFile(123456, "/master.css")
+	assert.NotNil(err)
+
+	entries, err := ReadJournal(&buf, JournalQuery{})
+	assert.Nil(err)
+	assert.Len(entries, 1)
+	assert.Equal([]string{"/master.css"}, entries[0].Files)
+	assert.Equal(400, entries[0].Code)
+	assert.Equal("Test Error: Test Error Message", entries[0].Error)
+}
+
+func TestReadJournal_query(t *testing.T) {
+	assert := assert.New(t)
+
+	journal := strings.Join([]string{
+		`{"time":"2014-07-01T10:00:00Z","zone":"1","op":"purge_zone"}`,
+		`{"time":"2014-07-02T10:00:00Z","zone":"2","op":"purge_zone"}`,
+		``,
+		`{"time":"2014-07-03T10:00:00Z","zone":"1","op":"purge_file","files":["/a.css"]}`,
+	}, "\n")
+
+	entries, err := ReadJournal(strings.NewReader(journal), JournalQuery{Zone: "1"})
+	assert.Nil(err)
+	assert.Len(entries, 2)
+
+	entries, err = ReadJournal(strings.NewReader(journal), JournalQuery{
+		From: time.Date(2014, 7, 2, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2014, 7, 3, 10, 0, 0, 0, time.UTC),
+	})
+	assert.Nil(err)
+	assert.Len(entries, 2)
+	assert.Equal("2", entries[0].Zone)
+	assert.Equal([]string{"/a.css"}, entries[1].Files)
+
+	_, err = ReadJournal(strings.NewReader("{"), JournalQuery{})
+	assert.NotNil(err)
+}
+
+func TestOpenJournal(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "maxcdn-journal")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "purges.jsonl")
+	for i := 0; i < 2; i++ {
+		j, err := OpenJournal(filename)
+		assert.Nil(err)
+		assert.Nil(j.Record(JournalEntry{Time: time.Now(), Zone: "1", Op: JournalPurgeZone}))
+		assert.Nil(j.Close())
+	}
+
+	entries, err := QueryJournal(filename, JournalQuery{Zone: "1"})
+	assert.Nil(err)
+	assert.Len(entries, 2)
+}
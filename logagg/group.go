@@ -0,0 +1,77 @@
+package logagg
+
+import "github.com/MaxCDN/go-maxcdn"
+
+// GroupFunc returns the group of a record.
+type GroupFunc func(r maxcdn.LogRecord) string
+
+// GroupByAgentKind groups records by the kind of their user agent, such as
+// "browser" or "crawler", using the default classifier.
+func GroupByAgentKind(r maxcdn.LogRecord) string {
+	return string(r.Agent().Kind)
+}
+
+// GroupByDevice groups records by the device class of their user agent,
+// such as "mobile", using the default classifier.
+func GroupByDevice(r maxcdn.LogRecord) string {
+	return string(r.Agent().Device)
+}
+
+// GroupByAgentKindWith is GroupByAgentKind using classifier c, such as the
+// Options.Classifier of the aggregator.
+func GroupByAgentKindWith(c *maxcdn.UserAgentClassifier) GroupFunc {
+	return func(r maxcdn.LogRecord) string {
+		return string(c.Classify(r.UserAgent).Kind)
+	}
+}
+
+// GroupByDeviceWith is GroupByDevice using classifier c.
+func GroupByDeviceWith(c *maxcdn.UserAgentClassifier) GroupFunc {
+	return func(r maxcdn.LogRecord) string {
+		return string(c.Classify(r.UserAgent).Device)
+	}
+}
+
+// GroupedAggregator builds a Report per group of records.
+type GroupedAggregator struct {
+	opts   Options
+	group  GroupFunc
+	groups map[string]*Aggregator
+}
+
+// NewGrouped returns an empty GroupedAggregator grouping records by group.
+func NewGrouped(opts Options, group GroupFunc) *GroupedAggregator {
+	return &GroupedAggregator{opts: opts, group: group, groups: map[string]*Aggregator{}}
+}
+
+// Add aggregates a record into its group.
+func (g *GroupedAggregator) Add(r maxcdn.LogRecord) {
+	key := g.group(r)
+	a, ok := g.groups[key]
+	if !ok {
+		a = New(g.opts)
+		g.groups[key] = a
+	}
+	a.Add(r)
+}
+
+// Reports returns the aggregate of each group's records added so far.
+func (g *GroupedAggregator) Reports() map[string]*Report {
+	reports := make(map[string]*Report, len(g.groups))
+	for key, a := range g.groups {
+		reports[key] = a.Report()
+	}
+	return reports
+}
+
+// AggregateBy drains a logs iterator into a report per group.
+func AggregateBy(it *maxcdn.LogIterator, opts Options, group GroupFunc) (map[string]*Report, error) {
+	g := NewGrouped(opts, group)
+	for it.Next() {
+		g.Add(it.Record())
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	return g.Reports(), nil
+}
@@ -0,0 +1,92 @@
+package logagg
+
+import (
+	"math"
+	"sort"
+)
+
+// DefaultHistogramAccuracy is the relative accuracy of a Histogram created
+// with an accuracy of zero.
+const DefaultHistogramAccuracy = 0.01
+
+// Histogram estimates quantiles of positive values, such as origin times,
+// with logarithmic buckets. Estimates are within the relative accuracy of
+// the true quantile, and memory grows with the logarithm of the range of
+// values rather than their number.
+type Histogram struct {
+	gamma   float64
+	buckets map[int]int64
+	zeros   int64
+	count   int64
+	min     float64
+	max     float64
+}
+
+// NewHistogram returns a histogram with a relative accuracy, such as 0.01
+// for 1%.
+func NewHistogram(accuracy float64) *Histogram {
+	if accuracy <= 0 || accuracy >= 1 {
+		accuracy = DefaultHistogramAccuracy
+	}
+	return &Histogram{
+		gamma:   (1 + accuracy) / (1 - accuracy),
+		buckets: map[int]int64{},
+	}
+}
+
+// Add records a value. Negative values are recorded as zero.
+func (h *Histogram) Add(v float64) {
+	if h.count == 0 || v < h.min {
+		h.min = v
+	}
+	if h.count == 0 || v > h.max {
+		h.max = v
+	}
+	h.count++
+
+	if v <= 0 {
+		h.zeros++
+		return
+	}
+	h.buckets[int(math.Ceil(math.Log(v)/math.Log(h.gamma)))]++
+}
+
+// Count returns the number of values recorded.
+func (h *Histogram) Count() int64 {
+	return h.count
+}
+
+// Quantile estimates the q quantile, between 0 and 1, or returns zero when
+// no values were recorded.
+func (h *Histogram) Quantile(q float64) float64 {
+	if h.count == 0 {
+		return 0
+	}
+	if q <= 0 {
+		return h.min
+	}
+	if q >= 1 {
+		return h.max
+	}
+
+	rank := int64(q * float64(h.count-1))
+	if rank < h.zeros {
+		return 0
+	}
+	seen := h.zeros
+
+	keys := make([]int, 0, len(h.buckets))
+	for k := range h.buckets {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+
+	for _, k := range keys {
+		seen += h.buckets[k]
+		if seen > rank {
+			v := 2 * math.Pow(h.gamma, float64(k)) / (h.gamma + 1)
+			return math.Min(math.Max(v, h.min), h.max)
+		}
+	}
+	return h.max
+}
@@ -0,0 +1,209 @@
+// Package logagg aggregates MaxCDN raw log records into a traffic report in
+// a single streaming pass. High cardinality breakdowns, such as URIs and
+// client IPs, are estimated with top-K sketches to keep memory bounded.
+package logagg
+
+import (
+	"strconv"
+
+	"github.com/MaxCDN/go-maxcdn"
+)
+
+// DefaultTop is the number of keys reported per top list when Options.Top
+// is zero.
+const DefaultTop = 10
+
+// Options configures an Aggregator.
+type Options struct {
+
+	// Top is the number of keys reported per top list.
+	Top int
+
+	// Capacity is the number of keys tracked per top list, ten times Top
+	// when zero. Higher is more accurate.
+	Capacity int
+
+	// Accuracy is the relative accuracy of the origin time percentiles,
+	// DefaultHistogramAccuracy when zero.
+	Accuracy float64
+
+	// Classifier classifies user agents, maxcdn.DefaultUserAgentClassifier
+	// when nil.
+	Classifier *maxcdn.UserAgentClassifier
+}
+
+// Percentiles summarizes a distribution.
+type Percentiles struct {
+	Count int64   `json:"count"`
+	P50   float64 `json:"p50"`
+	P95   float64 `json:"p95"`
+	P99   float64 `json:"p99"`
+}
+
+// Report is the aggregate of a stream of log records.
+type Report struct {
+	Records int64 `json:"records"`
+	Bytes   int64 `json:"bytes"`
+
+	TopURIs      []Count `json:"top_uris"`
+	TopClientIPs []Count `json:"top_client_ips"`
+	TopASNs      []Count `json:"top_asns"`
+
+	// StatusByPop counts records by POP, then status code.
+	StatusByPop map[string]map[int]int64 `json:"status_by_pop"`
+
+	CacheStatuses  map[string]int64 `json:"cache_statuses"`
+	BytesByCountry map[string]int64 `json:"bytes_by_country"`
+
+	// AgentKinds and Devices count records by user agent kind and device
+	// class.
+	AgentKinds map[maxcdn.AgentKind]int64   `json:"agent_kinds"`
+	Devices    map[maxcdn.DeviceClass]int64 `json:"devices"`
+
+	// OriginTime summarizes the origin time, in seconds, of the records
+	// which reached the origin.
+	OriginTime Percentiles `json:"origin_time"`
+}
+
+// Aggregator builds a Report from records added one at a time.
+type Aggregator struct {
+	top        int
+	classifier *maxcdn.UserAgentClassifier
+
+	records int64
+	bytes   int64
+
+	uris      *TopK
+	clientIPs *TopK
+	asns      *TopK
+
+	statusByPop    map[string]map[int]int64
+	cacheStatuses  map[string]int64
+	bytesByCountry map[string]int64
+	agentKinds     map[maxcdn.AgentKind]int64
+	devices        map[maxcdn.DeviceClass]int64
+
+	originTime *Histogram
+}
+
+// New returns an empty Aggregator.
+func New(opts Options) *Aggregator {
+	if opts.Top <= 0 {
+		opts.Top = DefaultTop
+	}
+	if opts.Capacity < opts.Top {
+		opts.Capacity = opts.Top * 10
+	}
+	if opts.Classifier == nil {
+		opts.Classifier = maxcdn.DefaultUserAgentClassifier
+	}
+
+	return &Aggregator{
+		top:            opts.Top,
+		classifier:     opts.Classifier,
+		uris:           NewTopK(opts.Capacity),
+		clientIPs:      NewTopK(opts.Capacity),
+		asns:           NewTopK(opts.Capacity),
+		statusByPop:    map[string]map[int]int64{},
+		cacheStatuses:  map[string]int64{},
+		bytesByCountry: map[string]int64{},
+		agentKinds:     map[maxcdn.AgentKind]int64{},
+		devices:        map[maxcdn.DeviceClass]int64{},
+		originTime:     NewHistogram(opts.Accuracy),
+	}
+}
+
+// Add aggregates a record.
+func (a *Aggregator) Add(r maxcdn.LogRecord) {
+	a.records++
+	a.bytes += int64(r.Bytes)
+
+	a.uris.Add(r.URI, 1)
+	a.clientIPs.Add(r.ClientIP, 1)
+	if r.ClientAsn != "" {
+		a.asns.Add(r.ClientAsn, 1)
+	}
+
+	statuses, ok := a.statusByPop[r.Pop]
+	if !ok {
+		statuses = map[int]int64{}
+		a.statusByPop[r.Pop] = statuses
+	}
+	statuses[r.Status]++
+
+	a.cacheStatuses[r.CacheStatus]++
+	a.bytesByCountry[r.ClientCountry] += int64(r.Bytes)
+
+	agent := a.classifier.Classify(r.UserAgent)
+	a.agentKinds[agent.Kind]++
+	a.devices[agent.Device]++
+
+	if r.OriginTime > 0 || r.IsMiss() {
+		a.originTime.Add(r.OriginTime)
+	}
+}
+
+// Report returns the aggregate of the records added so far.
+func (a *Aggregator) Report() *Report {
+	report := &Report{
+		Records:        a.records,
+		Bytes:          a.bytes,
+		TopURIs:        a.uris.Top(a.top),
+		TopClientIPs:   a.clientIPs.Top(a.top),
+		TopASNs:        a.asns.Top(a.top),
+		StatusByPop:    make(map[string]map[int]int64, len(a.statusByPop)),
+		CacheStatuses:  copyCounts(a.cacheStatuses),
+		BytesByCountry: copyCounts(a.bytesByCountry),
+		AgentKinds:     make(map[maxcdn.AgentKind]int64, len(a.agentKinds)),
+		Devices:        make(map[maxcdn.DeviceClass]int64, len(a.devices)),
+		OriginTime: Percentiles{
+			Count: a.originTime.Count(),
+			P50:   a.originTime.Quantile(0.50),
+			P95:   a.originTime.Quantile(0.95),
+			P99:   a.originTime.Quantile(0.99),
+		},
+	}
+	for kind, n := range a.agentKinds {
+		report.AgentKinds[kind] = n
+	}
+	for device, n := range a.devices {
+		report.Devices[device] = n
+	}
+	for pop, statuses := range a.statusByPop {
+		report.StatusByPop[pop] = make(map[int]int64, len(statuses))
+		for status, n := range statuses {
+			report.StatusByPop[pop][status] = n
+		}
+	}
+	return report
+}
+
+// StatusClasses returns the records of a POP by status class, such as
+// "2xx".
+func (r *Report) StatusClasses(pop string) map[string]int64 {
+	classes := map[string]int64{}
+	for status, n := range r.StatusByPop[pop] {
+		classes[strconv.Itoa(status/100)+"xx"] += n
+	}
+	return classes
+}
+
+// Aggregate drains a logs iterator into a report.
+func Aggregate(it *maxcdn.LogIterator, opts Options) (*Report, error) {
+	a := New(opts)
+	for it.Next() {
+		a.Add(it.Record())
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	return a.Report(), nil
+}
+
+func copyCounts(counts map[string]int64) map[string]int64 {
+	c := make(map[string]int64, len(counts))
+	for k, n := range counts {
+		c[k] = n
+	}
+	return c
+}
@@ -0,0 +1,134 @@
+package logagg
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/MaxCDN/go-maxcdn"
+	"github.com/stretchr/testify/assert"
+)
+
+type fixtureTransport struct{}
+
+func (fixtureTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	body, err := ioutil.ReadFile("../_fixtures/logs.json")
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(bytes.NewReader(body)), Request: r}, nil
+}
+
+func TestAggregate(t *testing.T) {
+	assert := assert.New(t)
+
+	max := maxcdn.NewMaxCDN("alias", "token", "secret")
+	max.HTTPClient = &http.Client{Transport: fixtureTransport{}}
+
+	report, err := Aggregate(max.IterateLogs(maxcdn.LogsQuery{}), Options{Top: 3})
+	assert.Nil(err)
+
+	assert.Equal(int64(54), report.Records)
+	assert.Equal(int64(15518+8334+4167+3017), report.Bytes)
+
+	assert.Len(report.TopURIs, 3)
+	assert.Equal(Count{Key: "/bootstrap/images/simple_icons/Facebook.png", Count: 8}, report.TopURIs[0])
+	assert.Equal(Count{Key: "119.130.187.162", Count: 7}, report.TopClientIPs[0])
+	assert.Equal(Count{Key: "AS701 MCI Communications Services, Inc. d/b/a Verizon Business", Count: 13}, report.TopASNs[0])
+
+	assert.Equal(map[int]int64{200: 14}, report.StatusByPop["dal"])
+	assert.Equal(map[string]int64{"2xx": 14}, report.StatusClasses("dal"))
+	assert.Equal(map[string]int64{"HIT": 46, "EXPIRED": 8}, report.CacheStatuses)
+	assert.Equal(int64(15518), report.BytesByCountry["US"])
+
+	// only the expired records reached the origin
+	assert.Equal(int64(8), report.OriginTime.Count)
+	assert.InDelta(0.129, report.OriginTime.P50, 0.129*0.01)
+	assert.InDelta(0.135, report.OriginTime.P99, 0.135*0.01)
+}
+
+func TestAggregateBy(t *testing.T) {
+	assert := assert.New(t)
+
+	max := maxcdn.NewMaxCDN("alias", "token", "secret")
+	max.HTTPClient = &http.Client{Transport: fixtureTransport{}}
+
+	reports, err := AggregateBy(max.IterateLogs(maxcdn.LogsQuery{}), Options{}, GroupByDevice)
+	assert.Nil(err)
+	assert.Len(reports, 1)
+	assert.Equal(int64(54), reports["desktop"].Records)
+	assert.Equal(map[maxcdn.AgentKind]int64{maxcdn.AgentBrowser: 54}, reports["desktop"].AgentKinds)
+	assert.Equal(map[maxcdn.DeviceClass]int64{maxcdn.DeviceDesktop: 54}, reports["desktop"].Devices)
+
+	g := NewGrouped(Options{}, GroupByAgentKind)
+	g.Add(maxcdn.LogRecord{UserAgent: "curl/7.35.0", Bytes: 10})
+	g.Add(maxcdn.LogRecord{UserAgent: "Wget/1.15", Bytes: 20})
+	g.Add(maxcdn.LogRecord{UserAgent: "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)"})
+	reports = g.Reports()
+	assert.Len(reports, 2)
+	assert.Equal(int64(2), reports["library"].Records)
+	assert.Equal(int64(30), reports["library"].Bytes)
+	assert.Equal(int64(1), reports["crawler"].Records)
+	assert.Equal(map[maxcdn.DeviceClass]int64{maxcdn.DeviceUnknown: 2}, reports["library"].Devices)
+
+	c, err := maxcdn.NewUserAgentClassifier(maxcdn.UserAgentRules{
+		Agents:  []maxcdn.UserAgentRule{{Name: "Safari", Kind: maxcdn.AgentCrawler, Pattern: "safari"}},
+		Devices: []maxcdn.DeviceRule{{Device: maxcdn.DeviceTablet, Pattern: "macintosh"}},
+	})
+	assert.Nil(err)
+	opts := Options{Classifier: c}
+
+	reports, err = AggregateBy(max.IterateLogs(maxcdn.LogsQuery{}), opts, GroupByAgentKindWith(opts.Classifier))
+	assert.Nil(err)
+	assert.Equal(int64(47), reports["crawler"].Records)
+	assert.Equal(int64(7), reports["unknown"].Records)
+	assert.Equal(map[maxcdn.AgentKind]int64{maxcdn.AgentCrawler: 47}, reports["crawler"].AgentKinds)
+
+	reports, err = AggregateBy(max.IterateLogs(maxcdn.LogsQuery{}), opts, GroupByDeviceWith(opts.Classifier))
+	assert.Nil(err)
+	assert.Equal(int64(40), reports["tablet"].Records)
+	assert.Equal(int64(14), reports["unknown"].Records)
+}
+
+func TestAggregate_err(t *testing.T) {
+	max := maxcdn.NewMaxCDN("alias", "token", "secret")
+	_, err := Aggregate(max.IterateLogs(maxcdn.LogsQuery{Limit: -1}), Options{})
+	assert.NotNil(t, err)
+}
+
+func TestTopK(t *testing.T) {
+	assert := assert.New(t)
+
+	top := NewTopK(3)
+	for _, key := range []string{"a", "a", "a", "a", "b", "b", "b", "c", "c", "d"} {
+		top.Add(key, 1)
+	}
+
+	// d replaced c, inheriting its count
+	assert.Equal([]Count{{Key: "a", Count: 4}, {Key: "b", Count: 3}, {Key: "d", Count: 3, Error: 2}}, top.Top(5))
+	assert.Equal([]Count{{Key: "a", Count: 4}}, top.Top(1))
+
+	top.Add("b", 5)
+	assert.Equal("b", top.Top(1)[0].Key)
+}
+
+func TestHistogram(t *testing.T) {
+	assert := assert.New(t)
+
+	h := NewHistogram(0.01)
+	assert.Equal(0.0, h.Quantile(0.5))
+
+	for i := 1; i <= 1000; i++ {
+		h.Add(float64(i) / 1000)
+	}
+	h.Add(0)
+
+	assert.Equal(int64(1001), h.Count())
+	assert.InDelta(0.5, h.Quantile(0.5), 0.5*0.01)
+	assert.InDelta(0.95, h.Quantile(0.95), 0.95*0.01)
+	assert.InDelta(0.99, h.Quantile(0.99), 0.99*0.01)
+	assert.Equal(0.0, h.Quantile(0))
+	assert.Equal(1.0, h.Quantile(1))
+	assert.True(len(h.buckets) < 400)
+}
@@ -0,0 +1,105 @@
+package logagg
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// Count is an estimated count of a key. The true count is between
+// Count-Error and Count.
+type Count struct {
+	Key   string `json:"key"`
+	Count int64  `json:"count"`
+	Error int64  `json:"error,omitempty"`
+}
+
+// TopK estimates the heaviest keys of a stream with the space-saving
+// algorithm, tracking at most a fixed number of keys. Counts are exact
+// while fewer keys than the capacity have been seen.
+type TopK struct {
+	capacity int
+	index    map[string]*topKEntry
+	entries  topKHeap
+}
+
+type topKEntry struct {
+	Count
+	pos int
+}
+
+// NewTopK returns a sketch tracking at most capacity keys. A larger capacity
+// than the number of keys reported makes the top counts more accurate.
+func NewTopK(capacity int) *TopK {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &TopK{capacity: capacity, index: make(map[string]*topKEntry, capacity)}
+}
+
+// Add counts weight for key.
+func (t *TopK) Add(key string, weight int64) {
+	if e, ok := t.index[key]; ok {
+		e.Count.Count += weight
+		heap.Fix(&t.entries, e.pos)
+		return
+	}
+
+	if len(t.entries) < t.capacity {
+		e := &topKEntry{Count: Count{Key: key, Count: weight}}
+		t.index[key] = e
+		heap.Push(&t.entries, e)
+		return
+	}
+
+	// Replace the lightest key, inheriting its count as the error.
+	e := t.entries[0]
+	delete(t.index, e.Key)
+	e.Error = e.Count.Count
+	e.Key = key
+	e.Count.Count += weight
+	t.index[key] = e
+	heap.Fix(&t.entries, 0)
+}
+
+// Top returns the n heaviest keys, by descending count then key.
+func (t *TopK) Top(n int) []Count {
+	counts := make([]Count, len(t.entries))
+	for i, e := range t.entries {
+		counts[i] = e.Count
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Key < counts[j].Key
+	})
+	if n >= 0 && n < len(counts) {
+		counts = counts[:n]
+	}
+	return counts
+}
+
+// topKHeap is a min-heap of entries by count.
+type topKHeap []*topKEntry
+
+func (h topKHeap) Len() int           { return len(h) }
+func (h topKHeap) Less(i, j int) bool { return h[i].Count.Count < h[j].Count.Count }
+
+func (h topKHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].pos = i
+	h[j].pos = j
+}
+
+func (h *topKHeap) Push(x interface{}) {
+	e := x.(*topKEntry)
+	e.pos = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *topKHeap) Pop() interface{} {
+	old := *h
+	e := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return e
+}
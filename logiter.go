@@ -0,0 +1,108 @@
+package maxcdn
+
+// LogIterator walks the records of a logs query, fetching pages as needed.
+//
+//	it := max.IterateLogs(q)
+//	for it.Next() {
+//		r := it.Record()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type LogIterator struct {
+	max *MaxCDN
+	q   LogsQuery
+
+	records []LogRecord
+	next    int
+	record  LogRecord
+
+	seen map[string]bool
+	done bool
+	err  error
+}
+
+// IterateLogs returns an iterator over the records of q, starting at
+// q.PageKey.
+func (max *MaxCDN) IterateLogs(q LogsQuery) *LogIterator {
+	return &LogIterator{max: max, q: q, seen: map[string]bool{}}
+}
+
+// Next advances to the next record matching the query's filter, returning
+// false when there are no more records or a page failed to load.
+func (it *LogIterator) Next() bool {
+	for {
+		for it.next >= len(it.records) {
+			if it.done || it.err != nil {
+				return false
+			}
+			it.fetch()
+		}
+
+		it.record = it.records[it.next]
+		it.next++
+		if it.q.Filter.Match(&it.record) {
+			return true
+		}
+	}
+}
+
+// fetch loads the next page. Paging stops on an empty or repeated page key,
+// or on a page shorter than the limit.
+func (it *LogIterator) fetch() {
+	if it.q.PageKey != "" {
+		it.seen[it.q.PageKey] = true
+	}
+
+	logs, err := it.max.GetLogsQuery(it.q)
+	if err != nil {
+		it.err = err
+		return
+	}
+	it.records, it.next = logs.Records, 0
+
+	limit := logs.Limit
+	if limit <= 0 {
+		limit = it.q.Limit
+	}
+
+	key := logs.NextPageKey
+	if key == "" || key == it.q.PageKey || it.seen[key] || len(logs.Records) < limit {
+		it.done = true
+	}
+	it.q.PageKey = key
+}
+
+// Record returns the current record.
+func (it *LogIterator) Record() LogRecord {
+	return it.record
+}
+
+// PageKey returns the key of the page after the records fetched so far,
+// which resumes the iteration when set as the PageKey of a new query.
+func (it *LogIterator) PageKey() string {
+	return it.q.PageKey
+}
+
+// Err returns the error which stopped the iteration, if any.
+func (it *LogIterator) Err() error {
+	return it.err
+}
+
+// Pipe writes the remaining records to w and flushes it. It returns the
+// number of records written.
+func (it *LogIterator) Pipe(w LogWriter) (int, error) {
+	var n int
+	for it.Next() {
+		if err := w.WriteRecord(it.Record()); err != nil {
+			return n, err
+		}
+		n++
+	}
+	if err := it.Err(); err != nil {
+		w.Flush()
+		return n, err
+	}
+	return n, w.Flush()
+}
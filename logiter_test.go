@@ -0,0 +1,112 @@
+package maxcdn
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// stubLogPages serves pages of two records, keyed by page_key, and records
+// the keys requested.
+func stubLogPages(pages map[string]string, keys *[]string) *http.Client {
+	return stubHTTPFunc(func(r *http.Request) (*http.Response, error) {
+		key := r.URL.Query().Get("page_key")
+		*keys = append(*keys, key)
+		if body, ok := pages[key]; ok {
+			return stubBodyResponse(r, 200, body), nil
+		}
+		return stubBodyResponse(r, 400, stubAPIError), nil
+	})
+}
+
+func TestLogIterator(t *testing.T) {
+	assert := assert.New(t)
+	max := NewMaxCDN("alias", "token", "secret")
+
+	var keys []string
+	max.HTTPClient = stubLogPages(map[string]string{
+		"":  `{"limit":2,"next_page_key":"a","records":[{"uri":"/1"},{"uri":"/2"}]}`,
+		"a": `{"limit":2,"next_page_key":"b","records":[{"uri":"/3"},{"uri":"/4"}]}`,
+		"b": `{"limit":2,"next_page_key":"c","records":[{"uri":"/5"}]}`,
+	}, &keys)
+
+	it := max.IterateLogs(LogsQuery{Limit: 2})
+	var uris []string
+	for it.Next() {
+		uris = append(uris, it.Record().URI)
+	}
+	assert.Nil(it.Err())
+	assert.Equal([]string{"/1", "/2", "/3", "/4", "/5"}, uris)
+	assert.Equal([]string{"", "a", "b"}, keys)
+	assert.Equal("c", it.PageKey())
+	assert.False(it.Next())
+}
+
+func TestLogIterator_repeatedKey(t *testing.T) {
+	assert := assert.New(t)
+	max := NewMaxCDN("alias", "token", "secret")
+
+	var keys []string
+	max.HTTPClient = stubLogPages(map[string]string{
+		"":  `{"limit":1,"next_page_key":"a","records":[{"uri":"/1"}]}`,
+		"a": `{"limit":1,"next_page_key":"","records":[]}`,
+		"b": `{"limit":1,"next_page_key":"a","records":[{"uri":"/2"}]}`,
+	}, &keys)
+
+	// an empty page with a new key moves on
+	it := max.IterateLogs(LogsQuery{PageKey: "b"})
+	assert.True(it.Next())
+	assert.Equal("/2", it.Record().URI)
+	assert.False(it.Next())
+	assert.Equal([]string{"b", "a"}, keys)
+
+	// the API going back to an earlier page stops the iteration
+	keys = nil
+	max.HTTPClient = stubLogPages(map[string]string{
+		"":  `{"limit":1,"next_page_key":"a","records":[{"uri":"/1"}]}`,
+		"a": `{"limit":1,"next_page_key":"a","records":[{"uri":"/2"}]}`,
+	}, &keys)
+	it = max.IterateLogs(LogsQuery{})
+	var n int
+	for it.Next() {
+		n++
+	}
+	assert.Equal(2, n)
+	assert.Equal([]string{"", "a"}, keys)
+}
+
+func TestLogIterator_err(t *testing.T) {
+	assert := assert.New(t)
+	max := NewMaxCDN("alias", "token", "secret")
+
+	var keys []string
+	max.HTTPClient = stubLogPages(map[string]string{
+		"":  `{"limit":1,"next_page_key":"a","records":[{"uri":"/1"}]}`,
+		"a": `not json`,
+	}, &keys)
+
+	var buf bytes.Buffer
+	n, err := max.IterateLogs(LogsQuery{}).Pipe(NewNDJSONLogWriter(&buf))
+	assert.NotNil(err)
+	assert.Equal(1, n)
+	assert.Equal(1, strings.Count(buf.String(), "\n"))
+
+	it := max.IterateLogs(LogsQuery{Limit: -1})
+	assert.False(it.Next())
+	assert.NotNil(it.Err())
+}
+
+func TestLogIterator_Pipe(t *testing.T) {
+	assert := assert.New(t)
+	max := NewMaxCDN("alias", "token", "secret")
+	max.HTTPClient = stubHTTPOk()
+
+	var buf bytes.Buffer
+	n, err := max.IterateLogs(LogsQuery{}).Pipe(NewCombinedLogWriter(&buf))
+	assert.Nil(err)
+	assert.Equal(54, n)
+	assert.Equal(54, strings.Count(buf.String(), "\n"))
+}
@@ -0,0 +1,75 @@
+package maxcdn
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// UnmarshalJSON decodes a log record, normalizing the "-" the API sends for
+// an empty referer.
+func (r *LogRecord) UnmarshalJSON(b []byte) error {
+	type rawLogRecord LogRecord
+
+	var raw rawLogRecord
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	*r = LogRecord(raw)
+
+	if r.Referer == "-" {
+		r.Referer = ""
+	}
+	return nil
+}
+
+// Timestamp parses the time of the request, returning the zero time when
+// it's missing or invalid.
+func (r LogRecord) Timestamp() time.Time {
+	ts, _ := time.Parse(time.RFC3339Nano, r.Time)
+	return ts
+}
+
+// URL returns the requested URL, rebuilt from the scheme, hostname, URI and
+// query string.
+func (r LogRecord) URL() string {
+	scheme := r.Scheme
+	if scheme == "" {
+		scheme = "http"
+	}
+
+	u := scheme + "://" + r.Hostname + r.URI
+	if qs := strings.TrimPrefix(r.QueryString, "?"); qs != "" {
+		u += "?" + qs
+	}
+	return u
+}
+
+// IsHit reports whether the request was served from cache, including stale
+// and revalidated content.
+func (r LogRecord) IsHit() bool {
+	switch strings.ToUpper(r.CacheStatus) {
+	case "HIT", "STALE", "UPDATING", "REVALIDATED":
+		return true
+	}
+	return false
+}
+
+// IsMiss reports whether the request was fetched from the origin.
+func (r LogRecord) IsMiss() bool {
+	switch strings.ToUpper(r.CacheStatus) {
+	case "MISS", "EXPIRED", "BYPASS":
+		return true
+	}
+	return false
+}
+
+// StatusClass returns the class of the status code, such as "2xx", or ""
+// for an invalid code.
+func (r LogRecord) StatusClass() string {
+	if r.Status < 100 || r.Status > 599 {
+		return ""
+	}
+	return fmt.Sprintf("%dxx", r.Status/100)
+}
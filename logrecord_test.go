@@ -0,0 +1,79 @@
+package maxcdn
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogRecord_UnmarshalJSON(t *testing.T) {
+	assert := assert.New(t)
+
+	var logs Logs
+	assert.Nil(json.Unmarshal(fetchJSON("logs.json"), &logs))
+
+	r := logs.Records[0]
+	assert.Equal(time.Date(2014, 7, 1, 16, 25, 51, 879000000, time.UTC), r.Timestamp())
+	assert.Equal("2014-07-01T16:25:51.879Z", r.Time)
+	assert.Equal("", r.Referer)
+	assert.Equal("http://cdn.mervine.net/bootstrap/favicon.ico", r.URL())
+	assert.True(r.IsHit())
+	assert.False(r.IsMiss())
+	assert.Equal("2xx", r.StatusClass())
+
+	var referers int
+	for _, r := range logs.Records {
+		assert.NotEqual("-", r.Referer)
+		if r.Referer != "" {
+			referers++
+		}
+	}
+	assert.Equal(49, referers)
+
+	var bad LogRecord
+	assert.Nil(json.Unmarshal([]byte(`{"time":"yesterday","status":200}`), &bad))
+	assert.Equal("yesterday", bad.Time)
+	assert.Equal(200, bad.Status)
+	assert.True(bad.Timestamp().IsZero())
+
+	var page Logs
+	assert.Nil(json.Unmarshal([]byte(`{"records":[{"time":"yesterday"},{"time":"2014-07-01T16:25:51Z"}]}`), &page))
+	assert.Len(page.Records, 2)
+	assert.True(page.Records[0].Timestamp().IsZero())
+	assert.False(page.Records[1].Timestamp().IsZero())
+
+	// decoded records equal hand-built ones, and follow changes to Time
+	assert.Equal(LogRecord{Time: "2014-07-01T16:25:51Z"}, page.Records[1])
+	page.Records[1].Time = "2014-07-02T16:25:51Z"
+	assert.Equal(time.Date(2014, 7, 2, 16, 25, 51, 0, time.UTC), page.Records[1].Timestamp())
+}
+
+func TestLogRecord_accessors(t *testing.T) {
+	assert := assert.New(t)
+
+	r := LogRecord{
+		Scheme:      "https",
+		Hostname:    "cdn.example.com",
+		URI:         "/a.css",
+		QueryString: "?v=1",
+		CacheStatus: "expired",
+		Status:      404,
+		Time:        "2014-07-01T16:25:51Z",
+	}
+	assert.Equal("https://cdn.example.com/a.css?v=1", r.URL())
+	assert.False(r.IsHit())
+	assert.True(r.IsMiss())
+	assert.Equal("4xx", r.StatusClass())
+
+	// built without decoding
+	assert.Equal(time.Date(2014, 7, 1, 16, 25, 51, 0, time.UTC), r.Timestamp())
+
+	r = LogRecord{CacheStatus: "-", Status: 0}
+	assert.False(r.IsHit())
+	assert.False(r.IsMiss())
+	assert.Equal("", r.StatusClass())
+	assert.True(r.Timestamp().IsZero())
+	assert.Equal("http://", r.URL())
+}
@@ -0,0 +1,171 @@
+package maxcdn
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Raw logs query limits.
+const (
+
+	// MaxLogsWindow is the longest time window of a single logs query.
+	MaxLogsWindow = 24 * time.Hour
+
+	// MaxLogsLimit is the largest number of records per page.
+	MaxLogsLimit = 1000
+)
+
+// logsTimeLayout is the format of the start and end parameters.
+const logsTimeLayout = "2006-01-02T15:04:05Z"
+
+// LogsQuery selects raw log records. Zero fields aren't filtered on.
+type LogsQuery struct {
+
+	// Start and End limit the records to a time window, of at most
+	// MaxLogsWindow. A zero End is now.
+	Start time.Time
+	End   time.Time
+
+	Zones         []int
+	StatusCodes   []int
+	CacheStatuses []string
+	Methods       []string
+
+	// URIContains matches URIs containing a string, and URIRegex those
+	// matching a regular expression.
+	URIContains string
+	URIRegex    string
+
+	// ClientCountry is a two letter country code, such as "US".
+	ClientCountry string
+
+	// Pop is an edge location, such as "atl".
+	Pop string
+
+	// Limit is the number of records per page, at most MaxLogsLimit.
+	Limit int
+
+	// PageKey selects the page starting after a previous page's
+	// NextPageKey.
+	PageKey string
+
+	// Filter drops records client-side, after the API's filters. It's
+	// applied by IterateLogs, TailLogs and LogArchiver, but not by
+	// GetLogsQuery or StreamLogsQuery.
+	Filter *LogFilter
+}
+
+// Validate checks q for errors the API would reject it for.
+func (q LogsQuery) Validate() error {
+	if !q.Start.IsZero() && !q.End.IsZero() {
+		if !q.End.After(q.Start) {
+			return fmt.Errorf("maxcdn: logs query end %s isn't after start %s", q.End.Format(time.RFC3339), q.Start.Format(time.RFC3339))
+		}
+		if q.End.Sub(q.Start) > MaxLogsWindow {
+			return fmt.Errorf("maxcdn: logs query window of %s exceeds %s", q.End.Sub(q.Start), MaxLogsWindow)
+		}
+	}
+
+	// The API ends queries without an End now.
+	if !q.Start.IsZero() && q.End.IsZero() && time.Since(q.Start) > MaxLogsWindow {
+		return fmt.Errorf("maxcdn: logs query window of %s since start exceeds %s", time.Since(q.Start).Truncate(time.Second), MaxLogsWindow)
+	}
+
+	for _, zone := range q.Zones {
+		if zone <= 0 {
+			return fmt.Errorf("maxcdn: invalid logs query zone %d", zone)
+		}
+	}
+	for _, code := range q.StatusCodes {
+		if code < 100 || code > 599 {
+			return fmt.Errorf("maxcdn: invalid logs query status code %d", code)
+		}
+	}
+	for _, status := range q.CacheStatuses {
+		if status == "" || strings.ContainsAny(status, ", ") {
+			return fmt.Errorf("maxcdn: invalid logs query cache status %q", status)
+		}
+	}
+	for _, method := range q.Methods {
+		if method == "" || strings.ToUpper(method) != method || strings.ContainsAny(method, ", ") {
+			return fmt.Errorf("maxcdn: invalid logs query method %q", method)
+		}
+	}
+
+	if q.URIRegex != "" {
+		if _, err := regexp.Compile(q.URIRegex); err != nil {
+			return fmt.Errorf("maxcdn: invalid logs query uri regex: %v", err)
+		}
+	}
+	if q.ClientCountry != "" && len(q.ClientCountry) != 2 {
+		return fmt.Errorf("maxcdn: invalid logs query client country %q", q.ClientCountry)
+	}
+	if q.Limit < 0 || q.Limit > MaxLogsLimit {
+		return fmt.Errorf("maxcdn: logs query limit %d isn't between 0 and %d", q.Limit, MaxLogsLimit)
+	}
+	return nil
+}
+
+// Values encodes the query parameters of q. It doesn't validate q.
+func (q LogsQuery) Values() url.Values {
+	form := url.Values{}
+	if !q.Start.IsZero() {
+		form.Set("start", q.Start.UTC().Format(logsTimeLayout))
+	}
+	if !q.End.IsZero() {
+		form.Set("end", q.End.UTC().Format(logsTimeLayout))
+	}
+
+	if len(q.Zones) > 0 {
+		form.Set("zones", joinInts(q.Zones))
+	}
+	if len(q.StatusCodes) > 0 {
+		form.Set("status", joinInts(q.StatusCodes))
+	}
+	if len(q.CacheStatuses) > 0 {
+		form.Set("cache_status", strings.Join(q.CacheStatuses, ","))
+	}
+	if len(q.Methods) > 0 {
+		form.Set("method", strings.Join(q.Methods, ","))
+	}
+
+	if q.URIContains != "" {
+		form.Set("uri", q.URIContains)
+	}
+	if q.URIRegex != "" {
+		form.Set("uri_regex", q.URIRegex)
+	}
+	if q.ClientCountry != "" {
+		form.Set("client_country", strings.ToUpper(q.ClientCountry))
+	}
+	if q.Pop != "" {
+		form.Set("pop", q.Pop)
+	}
+	if q.Limit > 0 {
+		form.Set("limit", strconv.Itoa(q.Limit))
+	}
+	if q.PageKey != "" {
+		form.Set("page_key", q.PageKey)
+	}
+	return form
+}
+
+// GetLogsQuery validates q and fetches a page of logs.
+func (max *MaxCDN) GetLogsQuery(q LogsQuery) (Logs, error) {
+	if err := q.Validate(); err != nil {
+		return Logs{}, err
+	}
+	return max.GetLogs(q.Values())
+}
+
+func joinInts(ints []int) string {
+	s := make([]string, len(ints))
+	for i, n := range ints {
+		s[i] = strconv.Itoa(n)
+	}
+	return strings.Join(s, ",")
+}
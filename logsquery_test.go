@@ -0,0 +1,93 @@
+package maxcdn
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogsQuery_Values(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("", LogsQuery{}.Values().Encode())
+
+	est := time.FixedZone("EST", -5*3600)
+	q := LogsQuery{
+		Start:         time.Date(2014, 7, 1, 11, 0, 0, 0, est),
+		End:           time.Date(2014, 7, 1, 12, 30, 0, 0, est),
+		Zones:         []int{164197, 164198},
+		StatusCodes:   []int{404, 500},
+		CacheStatuses: []string{"MISS", "EXPIRED"},
+		Methods:       []string{"GET", "HEAD"},
+		URIContains:   "/bootstrap",
+		URIRegex:      `\.css$`,
+		ClientCountry: "us",
+		Pop:           "atl",
+		Limit:         500,
+		PageKey:       "1404229642374",
+	}
+
+	form := q.Values()
+	assert.Equal("2014-07-01T16:00:00Z", form.Get("start"))
+	assert.Equal("2014-07-01T17:30:00Z", form.Get("end"))
+	assert.Equal("164197,164198", form.Get("zones"))
+	assert.Equal("404,500", form.Get("status"))
+	assert.Equal("MISS,EXPIRED", form.Get("cache_status"))
+	assert.Equal("GET,HEAD", form.Get("method"))
+	assert.Equal("/bootstrap", form.Get("uri"))
+	assert.Equal(`\.css$`, form.Get("uri_regex"))
+	assert.Equal("US", form.Get("client_country"))
+	assert.Equal("atl", form.Get("pop"))
+	assert.Equal("500", form.Get("limit"))
+	assert.Equal("1404229642374", form.Get("page_key"))
+
+	assert.Nil(q.Validate())
+}
+
+func TestLogsQuery_Validate(t *testing.T) {
+	assert := assert.New(t)
+	start := time.Date(2014, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, q := range []LogsQuery{
+		{Start: start, End: start},
+		{Start: start, End: start.Add(-time.Hour)},
+		{Start: start, End: start.Add(MaxLogsWindow + time.Second)},
+		{Zones: []int{0}},
+		{StatusCodes: []int{99}},
+		{StatusCodes: []int{600}},
+		{CacheStatuses: []string{""}},
+		{Methods: []string{"get"}},
+		{URIRegex: "("},
+		{ClientCountry: "USA"},
+		{Limit: -1},
+		{Limit: MaxLogsLimit + 1},
+		{Start: start},
+		{Start: time.Now().Add(-MaxLogsWindow - time.Minute)},
+	} {
+		assert.NotNil(q.Validate(), "%+v", q)
+	}
+
+	assert.Nil(LogsQuery{Start: time.Now().Add(-time.Hour)}.Validate())
+	assert.Nil(LogsQuery{Start: start, End: start.Add(MaxLogsWindow)}.Validate())
+}
+
+func TestMaxCDN_GetLogsQuery(t *testing.T) {
+	assert := assert.New(t)
+	max := NewMaxCDN("alias", "token", "secret")
+
+	var recorder http.Response
+	max.HTTPClient = stubHTTPOkRecorded(&recorder)
+
+	logs, err := max.GetLogsQuery(LogsQuery{Zones: []int{164197}, Limit: 100})
+	assert.Nil(err)
+	assert.Equal("1404229642374", logs.NextPageKey)
+	assert.Equal("/alias/v3/reporting/logs.json", recorder.Request.URL.Path)
+	assert.Equal("limit=100&zones=164197", recorder.Request.URL.Query().Encode())
+
+	recorder.Request = nil
+	_, err = max.GetLogsQuery(LogsQuery{Limit: -1})
+	assert.NotNil(err)
+	assert.Nil(recorder.Request)
+}
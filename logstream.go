@@ -0,0 +1,109 @@
+package maxcdn
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+)
+
+// DecodeLogs decodes a logs page from r, calling fn with each record as it's
+// read rather than collecting them, so memory use doesn't grow with the
+// page size. It returns the page's other fields, with Records left nil.
+//
+// Decoding stops at the first error returned by fn, which DecodeLogs
+// returns.
+func DecodeLogs(r io.Reader, fn func(LogRecord) error) (Logs, error) {
+	var logs Logs
+	dec := json.NewDecoder(r)
+
+	if err := expectDelim(dec, '{'); err != nil {
+		return logs, err
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return logs, err
+		}
+		key, _ := tok.(string)
+
+		switch key {
+		case "records":
+			err = decodeLogRecords(dec, fn)
+		case "limit":
+			err = dec.Decode(&logs.Limit)
+		case "next_page_key":
+			err = dec.Decode(&logs.NextPageKey)
+		case "page":
+			err = dec.Decode(&logs.Page)
+		case "request_time":
+			err = dec.Decode(&logs.RequestTime)
+		default:
+			var skip json.RawMessage
+			err = dec.Decode(&skip)
+		}
+		if err != nil {
+			return logs, err
+		}
+	}
+	return logs, expectDelim(dec, '}')
+}
+
+// decodeLogRecords decodes the records array, which may be null.
+func decodeLogRecords(dec *json.Decoder, fn func(LogRecord) error) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok == nil {
+		return nil
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("maxcdn: logs records isn't an array")
+	}
+
+	for dec.More() {
+		var record LogRecord
+		if err := dec.Decode(&record); err != nil {
+			return err
+		}
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+	return expectDelim(dec, ']')
+}
+
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != want {
+		return fmt.Errorf("maxcdn: unexpected %v in logs, expected %v", tok, want)
+	}
+	return nil
+}
+
+// StreamLogs is GetLogs for large pages: it calls fn with each record as
+// it's decoded, as DecodeLogs does, instead of collecting them in
+// Logs.Records.
+func (max *MaxCDN) StreamLogs(form url.Values, fn func(LogRecord) error) (Logs, error) {
+	rsp, err := max.Request("GET", logsPath, form)
+	if err != nil {
+		return Logs{}, err
+	}
+
+	logs, err := DecodeLogs(rsp.Body, fn)
+	_ = rsp.Body.Close()
+	return logs, err
+}
+
+// StreamLogsQuery validates q and streams a page of logs. Like GetLogsQuery
+// it doesn't apply q.Filter.
+func (max *MaxCDN) StreamLogsQuery(q LogsQuery, fn func(LogRecord) error) (Logs, error) {
+	if err := q.Validate(); err != nil {
+		return Logs{}, err
+	}
+	return max.StreamLogs(q.Values(), fn)
+}
@@ -0,0 +1,149 @@
+package maxcdn
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeLogs(t *testing.T) {
+	assert := assert.New(t)
+
+	var want Logs
+	assert.Nil(json.Unmarshal(fetchJSON("logs.json"), &want))
+
+	var records []LogRecord
+	logs, err := DecodeLogs(bytes.NewReader(fetchJSON("logs.json")), func(r LogRecord) error {
+		records = append(records, r)
+		return nil
+	})
+	assert.Nil(err)
+	assert.Equal(want.Records, records)
+	want.Records = nil
+	assert.Equal(want, logs)
+	assert.Equal("1404229642374", logs.NextPageKey)
+	assert.Equal(25, logs.RequestTime)
+
+	// fields after the records, unknown fields and null records
+	logs, err = DecodeLogs(strings.NewReader(`{"records":null,"extra":{"a":[1]},"next_page_key":"k"}`), func(LogRecord) error {
+		t.Error("unexpected record")
+		return nil
+	})
+	assert.Nil(err)
+	assert.Equal(Logs{NextPageKey: "k"}, logs)
+}
+
+func TestDecodeLogs_errors(t *testing.T) {
+	assert := assert.New(t)
+	noop := func(LogRecord) error { return nil }
+
+	for _, body := range []string{
+		``,
+		`Bad Gateway`,
+		`[]`,
+		`{"records":{}}`,
+		`{"records":[{"time":5}]}`,
+		`{"records":[{"bytes":1}`,
+		`{"limit":"100"}`,
+	} {
+		_, err := DecodeLogs(strings.NewReader(body), noop)
+		assert.NotNil(err, body)
+	}
+
+	stop := errors.New("stop")
+	var n int
+	_, err := DecodeLogs(bytes.NewReader(fetchJSON("logs.json")), func(LogRecord) error {
+		if n++; n == 3 {
+			return stop
+		}
+		return nil
+	})
+	assert.Equal(stop, err)
+	assert.Equal(3, n)
+}
+
+func TestStreamLogsQuery(t *testing.T) {
+	assert := assert.New(t)
+	max := NewMaxCDN("alias", "token", "secret")
+	max.HTTPClient = stubHTTPOk()
+
+	var hits int
+	logs, err := max.StreamLogsQuery(LogsQuery{Limit: 100}, func(r LogRecord) error {
+		if r.IsHit() {
+			hits++
+		}
+		return nil
+	})
+	assert.Nil(err)
+	assert.Equal(46, hits)
+	assert.Equal(100, logs.Limit)
+	assert.Nil(logs.Records)
+
+	_, err = max.StreamLogsQuery(LogsQuery{Limit: -1}, func(LogRecord) error { return nil })
+	assert.NotNil(err)
+}
+
+// benchmarkLogsPage is a page of MaxLogsLimit records, built from the
+// records of _fixtures/logs.json.
+func benchmarkLogsPage(b *testing.B) []byte {
+	var logs Logs
+	if err := json.Unmarshal(fetchJSON("logs.json"), &logs); err != nil {
+		b.Fatal(err)
+	}
+
+	page := logs
+	page.Limit, page.Records = MaxLogsLimit, nil
+	for len(page.Records) < MaxLogsLimit {
+		page.Records = append(page.Records, logs.Records[len(page.Records)%len(logs.Records)])
+	}
+
+	body, err := json.Marshal(page)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return body
+}
+
+func benchmarkLogsClient(body []byte) *MaxCDN {
+	max := NewMaxCDN("alias", "token", "secret")
+	max.HTTPClient = stubHTTPFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(bytes.NewReader(body)), Request: r}, nil
+	})
+	return max
+}
+
+func BenchmarkGetLogs(b *testing.B) {
+	max := benchmarkLogsClient(benchmarkLogsPage(b))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		logs, err := max.GetLogs(nil)
+		if err != nil || len(logs.Records) != MaxLogsLimit {
+			b.Fatal(len(logs.Records), err)
+		}
+	}
+}
+
+func BenchmarkStreamLogs(b *testing.B) {
+	max := benchmarkLogsClient(benchmarkLogsPage(b))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var n int
+		_, err := max.StreamLogs(nil, func(LogRecord) error {
+			n++
+			return nil
+		})
+		if err != nil || n != MaxLogsLimit {
+			b.Fatal(n, err)
+		}
+	}
+}
@@ -0,0 +1,278 @@
+package maxcdn
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// LogWriter renders log records in an access log format. Records may be
+// buffered until Flush.
+type LogWriter interface {
+	WriteRecord(r LogRecord) error
+	Flush() error
+}
+
+// LogFields lists the fields of LogRecord by JSON name, in struct order.
+var LogFields []string
+
+// logFieldIndex maps the JSON name of a LogRecord field to its index.
+var logFieldIndex = map[string]int{}
+
+func init() {
+	t := reflect.TypeOf(LogRecord{})
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		LogFields = append(LogFields, name)
+		logFieldIndex[name] = i
+	}
+}
+
+// Field returns a field by JSON name, such as "client_ip", formatted as a
+// string, and false for unknown fields.
+func (r LogRecord) Field(name string) (string, bool) {
+	i, ok := logFieldIndex[name]
+	if !ok {
+		return "", false
+	}
+
+	v := reflect.ValueOf(r).Field(i)
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), true
+	case reflect.Int:
+		return strconv.FormatInt(v.Int(), 10), true
+	case reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64), true
+	}
+	return fmt.Sprint(v.Interface()), true
+}
+
+func checkLogFields(fields []string) error {
+	for _, f := range fields {
+		if _, ok := logFieldIndex[f]; !ok {
+			return fmt.Errorf("maxcdn: unknown log field %q", f)
+		}
+	}
+	return nil
+}
+
+// combinedTimeLayout is the time format of the Combined Log Format.
+const combinedTimeLayout = "02/Jan/2006:15:04:05 -0700"
+
+type combinedLogWriter struct {
+	w *bufio.Writer
+}
+
+// NewCombinedLogWriter returns a LogWriter for the NCSA Combined Log
+// Format, as written by Apache and read by GoAccess.
+func NewCombinedLogWriter(w io.Writer) LogWriter {
+	return &combinedLogWriter{w: bufio.NewWriter(w)}
+}
+
+func (c *combinedLogWriter) WriteRecord(r LogRecord) error {
+	request := r.URI
+	if r.QueryString != "" {
+		request += "?" + strings.TrimPrefix(r.QueryString, "?")
+	}
+	if r.Method != "" {
+		request = r.Method + " " + request
+	}
+	if r.Protocol != "" {
+		request += " " + r.Protocol
+	}
+
+	bytes := "-"
+	if r.Bytes > 0 {
+		bytes = strconv.Itoa(r.Bytes)
+	}
+
+	// Records without a valid time get "-" rather than a made up date.
+	ts := "-"
+	if t := r.Timestamp(); !t.IsZero() {
+		ts = "[" + t.Format(combinedTimeLayout) + "]"
+	}
+
+	_, err := fmt.Fprintf(c.w, "%s - - %s %s %d %s %s %s\n",
+		dash(r.ClientIP),
+		ts,
+		combinedQuote(request),
+		r.Status,
+		bytes,
+		combinedQuote(dash(r.Referer)),
+		combinedQuote(dash(r.UserAgent)))
+	return err
+}
+
+func (c *combinedLogWriter) Flush() error {
+	return c.w.Flush()
+}
+
+func combinedQuote(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	return `"` + strings.Replace(s, `"`, `\"`, -1) + `"`
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// W3C Extended Log Format fields. The x- fields are MaxCDN specific.
+var w3cFields = map[string]func(LogRecord) string{
+	"date":             func(r LogRecord) string { return formatLogTime(r, "2006-01-02") },
+	"time":             func(r LogRecord) string { return formatLogTime(r, "15:04:05") },
+	"c-ip":             func(r LogRecord) string { return r.ClientIP },
+	"cs-method":        func(r LogRecord) string { return r.Method },
+	"cs-uri-stem":      func(r LogRecord) string { return r.URI },
+	"cs-uri-query":     func(r LogRecord) string { return strings.TrimPrefix(r.QueryString, "?") },
+	"cs-uri":           func(r LogRecord) string { return r.URL() },
+	"cs-host":          func(r LogRecord) string { return r.Hostname },
+	"cs-version":       func(r LogRecord) string { return r.Protocol },
+	"cs(User-Agent)":   func(r LogRecord) string { return r.UserAgent },
+	"cs(Referer)":      func(r LogRecord) string { return r.Referer },
+	"sc-status":        func(r LogRecord) string { return strconv.Itoa(r.Status) },
+	"sc-bytes":         func(r LogRecord) string { return strconv.Itoa(r.Bytes) },
+	"time-taken":       func(r LogRecord) string { return strconv.FormatFloat(r.OriginTime, 'f', -1, 64) },
+	"x-cache-status":   func(r LogRecord) string { return r.CacheStatus },
+	"x-edge-location":  func(r LogRecord) string { return r.Pop },
+	"x-zone-id":        func(r LogRecord) string { return strconv.Itoa(r.ZoneID) },
+	"x-client-country": func(r LogRecord) string { return r.ClientCountry },
+	"x-scheme":         func(r LogRecord) string { return r.Scheme },
+}
+
+// DefaultW3CFields are written when no W3C fields are given.
+var DefaultW3CFields = []string{
+	"date", "time", "c-ip", "cs-method", "cs-host", "cs-uri-stem", "cs-uri-query",
+	"sc-status", "sc-bytes", "time-taken", "cs(Referer)", "cs(User-Agent)",
+	"x-cache-status", "x-edge-location",
+}
+
+type w3cLogWriter struct {
+	w      *bufio.Writer
+	fields []string
+	header bool
+}
+
+// NewW3CLogWriter returns a LogWriter for the W3C Extended Log Format,
+// writing fields in order, or DefaultW3CFields when empty. The directives
+// are written before the first record.
+func NewW3CLogWriter(w io.Writer, fields []string) (LogWriter, error) {
+	if len(fields) == 0 {
+		fields = DefaultW3CFields
+	}
+	for _, f := range fields {
+		if _, ok := w3cFields[f]; !ok {
+			return nil, fmt.Errorf("maxcdn: unknown W3C log field %q", f)
+		}
+	}
+	return &w3cLogWriter{w: bufio.NewWriter(w), fields: fields}, nil
+}
+
+func (c *w3cLogWriter) WriteRecord(r LogRecord) error {
+	if !c.header {
+		c.header = true
+		fmt.Fprintf(c.w, "#Version: 1.0\n#Fields: %s\n", strings.Join(c.fields, " "))
+	}
+
+	values := make([]string, len(c.fields))
+	for i, f := range c.fields {
+		values[i] = w3cValue(w3cFields[f](r))
+	}
+	_, err := fmt.Fprintln(c.w, strings.Join(values, " "))
+	return err
+}
+
+func (c *w3cLogWriter) Flush() error {
+	return c.w.Flush()
+}
+
+// formatLogTime formats the UTC time of a record, or returns an empty string
+// for records without a valid time.
+func formatLogTime(r LogRecord, layout string) string {
+	t := r.Timestamp()
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(layout)
+}
+
+// w3cValue replaces empty values with "-" and quotes those with spaces.
+func w3cValue(s string) string {
+	if s == "" {
+		return "-"
+	}
+	if strings.ContainsAny(s, " \t\"") {
+		return `"` + strings.Replace(s, `"`, `""`, -1) + `"`
+	}
+	return s
+}
+
+type csvLogWriter struct {
+	w      *csv.Writer
+	fields []string
+	header bool
+}
+
+// NewCSVLogWriter returns a LogWriter for CSV, with a header row and a
+// column per field, named as in LogFields. Every field is written when
+// fields is empty.
+func NewCSVLogWriter(w io.Writer, fields []string) (LogWriter, error) {
+	if len(fields) == 0 {
+		fields = LogFields
+	}
+	if err := checkLogFields(fields); err != nil {
+		return nil, err
+	}
+	return &csvLogWriter{w: csv.NewWriter(w), fields: fields}, nil
+}
+
+func (c *csvLogWriter) WriteRecord(r LogRecord) error {
+	if !c.header {
+		c.header = true
+		if err := c.w.Write(c.fields); err != nil {
+			return err
+		}
+	}
+
+	row := make([]string, len(c.fields))
+	for i, f := range c.fields {
+		row[i], _ = r.Field(f)
+	}
+	return c.w.Write(row)
+}
+
+func (c *csvLogWriter) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type ndjsonLogWriter struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+// NewNDJSONLogWriter returns a LogWriter for newline delimited JSON, with
+// an object per record in the API's format.
+func NewNDJSONLogWriter(w io.Writer) LogWriter {
+	bw := bufio.NewWriter(w)
+	return &ndjsonLogWriter{w: bw, enc: json.NewEncoder(bw)}
+}
+
+func (c *ndjsonLogWriter) WriteRecord(r LogRecord) error {
+	return c.enc.Encode(r)
+}
+
+func (c *ndjsonLogWriter) Flush() error {
+	return c.w.Flush()
+}
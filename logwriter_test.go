@@ -0,0 +1,122 @@
+package maxcdn
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testLogRecord = LogRecord{
+	Bytes:       1150,
+	CacheStatus: "HIT",
+	ClientIP:    "24.178.104.66",
+	Hostname:    "cdn.mervine.net",
+	Method:      "GET",
+	OriginTime:  0.25,
+	Pop:         "atl",
+	Protocol:    "HTTP/1.1",
+	QueryString: "v=1",
+	Scheme:      "http",
+	Status:      200,
+	Time:        "2014-07-01T16:25:51.879Z",
+	URI:         "/favicon.ico",
+	UserAgent:   `Mozilla/5.0 "quoted"`,
+	ZoneID:      164197,
+}
+
+func TestLogRecord_Field(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("client_ip", LogFields[7])
+	assert.Len(LogFields, 25)
+
+	v, ok := testLogRecord.Field("bytes")
+	assert.True(ok)
+	assert.Equal("1150", v)
+
+	v, _ = testLogRecord.Field("origin_time")
+	assert.Equal("0.25", v)
+
+	_, ok = testLogRecord.Field("timestamp")
+	assert.False(ok)
+}
+
+func TestCombinedLogWriter(t *testing.T) {
+	assert := assert.New(t)
+
+	var buf bytes.Buffer
+	w := NewCombinedLogWriter(&buf)
+	assert.Nil(w.WriteRecord(testLogRecord))
+	assert.Nil(w.WriteRecord(LogRecord{}))
+	assert.Nil(w.Flush())
+
+	assert.Equal(`24.178.104.66 - - [01/Jul/2014:16:25:51 +0000] "GET /favicon.ico?v=1 HTTP/1.1" 200 1150 "-" "Mozilla/5.0 \"quoted\""`+"\n"+
+		`- - - - "" 0 - "-" "-"`+"\n", buf.String())
+}
+
+func TestW3CLogWriter(t *testing.T) {
+	assert := assert.New(t)
+
+	var buf bytes.Buffer
+	w, err := NewW3CLogWriter(&buf, []string{"date", "time", "c-ip", "cs-uri-stem", "cs(Referer)", "cs(User-Agent)", "time-taken", "x-cache-status"})
+	assert.Nil(err)
+	assert.Nil(w.WriteRecord(testLogRecord))
+	assert.Nil(w.WriteRecord(LogRecord{Time: "yesterday", URI: "/a.css"}))
+	assert.Nil(w.Flush())
+
+	assert.Equal("#Version: 1.0\n"+
+		"#Fields: date time c-ip cs-uri-stem cs(Referer) cs(User-Agent) time-taken x-cache-status\n"+
+		`2014-07-01 16:25:51 24.178.104.66 /favicon.ico - "Mozilla/5.0 ""quoted""" 0.25 HIT`+"\n"+
+		`- - - /a.css - - 0 -`+"\n", buf.String())
+
+	w, err = NewW3CLogWriter(&buf, nil)
+	assert.Nil(err)
+	assert.NotNil(w)
+
+	_, err = NewW3CLogWriter(&buf, []string{"cs-bogus"})
+	assert.NotNil(err)
+}
+
+func TestCSVLogWriter(t *testing.T) {
+	assert := assert.New(t)
+
+	var buf bytes.Buffer
+	w, err := NewCSVLogWriter(&buf, []string{"time", "client_ip", "status", "user_agent"})
+	assert.Nil(err)
+	assert.Nil(w.WriteRecord(testLogRecord))
+	assert.Nil(w.Flush())
+
+	assert.Equal("time,client_ip,status,user_agent\n"+
+		`2014-07-01T16:25:51.879Z,24.178.104.66,200,"Mozilla/5.0 ""quoted"""`+"\n", buf.String())
+
+	buf.Reset()
+	w, err = NewCSVLogWriter(&buf, nil)
+	assert.Nil(err)
+	assert.Nil(w.WriteRecord(testLogRecord))
+	assert.Nil(w.Flush())
+	assert.Equal(strings.Join(LogFields, ","), strings.SplitN(buf.String(), "\n", 2)[0])
+
+	_, err = NewCSVLogWriter(&buf, []string{"bogus"})
+	assert.NotNil(err)
+}
+
+func TestNDJSONLogWriter(t *testing.T) {
+	assert := assert.New(t)
+
+	var buf bytes.Buffer
+	w := NewNDJSONLogWriter(&buf)
+	assert.Nil(w.WriteRecord(testLogRecord))
+	assert.Nil(w.WriteRecord(testLogRecord))
+	assert.Nil(w.Flush())
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(lines, 2)
+
+	var r LogRecord
+	assert.Nil(json.Unmarshal([]byte(lines[0]), &r))
+	assert.Equal(testLogRecord.URL(), r.URL())
+	assert.Equal(testLogRecord.Timestamp(), r.Timestamp())
+}
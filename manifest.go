@@ -0,0 +1,189 @@
+package maxcdn
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Manifest maps the files of a build to their content hash.
+type Manifest map[string]string
+
+// ReadManifest decodes a JSON object of path to content hash.
+func ReadManifest(r io.Reader) (Manifest, error) {
+	var m Manifest
+	if err := json.NewDecoder(r).Decode(&m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// LoadManifest reads a JSON manifest from a file.
+func LoadManifest(filename string) (Manifest, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ReadManifest(f)
+}
+
+// WalkManifest builds a manifest from the regular files below dir, keyed by
+// their slash separated path relative to dir and hashed with SHA-256.
+func WalkManifest(dir string) (Manifest, error) {
+	m := Manifest{}
+
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+
+		sum, err := hashFile(p)
+		if err != nil {
+			return err
+		}
+		m[filepath.ToSlash(rel)] = sum
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ManifestDiff holds the files which differ between two manifests, sorted.
+type ManifestDiff struct {
+	Added   []string
+	Changed []string
+	Removed []string
+}
+
+// DiffManifests compares the manifests of two builds.
+func DiffManifests(from, to Manifest) ManifestDiff {
+	var diff ManifestDiff
+
+	for file, sum := range to {
+		prev, ok := from[file]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, file)
+		case prev != sum:
+			diff.Changed = append(diff.Changed, file)
+		}
+	}
+	for file := range from {
+		if _, ok := to[file]; !ok {
+			diff.Removed = append(diff.Removed, file)
+		}
+	}
+
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Changed)
+	sort.Strings(diff.Removed)
+	return diff
+}
+
+// Files returns every added, changed and removed file, sorted.
+func (d ManifestDiff) Files() []string {
+	files := make([]string, 0, len(d.Added)+len(d.Changed)+len(d.Removed))
+	files = append(files, d.Added...)
+	files = append(files, d.Changed...)
+	files = append(files, d.Removed...)
+	sort.Strings(files)
+	return files
+}
+
+// PathRewrite maps manifest paths to CDN paths by replacing the From
+// directory with To. Paths outside From are kept as is.
+type PathRewrite struct {
+	From string
+	To   string
+}
+
+// Rewrite maps a single manifest path to its CDN path.
+func (rw PathRewrite) Rewrite(file string) string {
+	file = filepath.ToSlash(file)
+	from := strings.TrimSuffix(rw.From, "/")
+	switch {
+	case from == "":
+		file = rw.To + "/" + file
+	case file == from:
+		file = rw.To
+	case strings.HasPrefix(file, from+"/"):
+		file = rw.To + "/" + file[len(from)+1:]
+	}
+	return path.Clean(normalizeFile(file))
+}
+
+// ManifestPurgeSummary reports what PurgeManifestDiff purged, as CDN paths.
+type ManifestPurgeSummary struct {
+	ManifestDiff
+	Purged    int
+	Responses []*Response
+}
+
+// PurgeManifestDiff purges the files added, changed or removed between two
+// manifests from a zone with PurgeFiles. Added files are purged too, as an
+// edge may have cached a miss for them.
+func (max *MaxCDN) PurgeManifestDiff(zone int, from, to Manifest, rw PathRewrite) (*ManifestPurgeSummary, error) {
+	diff := DiffManifests(from, to)
+
+	summary := &ManifestPurgeSummary{
+		ManifestDiff: ManifestDiff{
+			Added:   rw.rewriteAll(diff.Added),
+			Changed: rw.rewriteAll(diff.Changed),
+			Removed: rw.rewriteAll(diff.Removed),
+		},
+	}
+
+	files := summary.Files()
+	if len(files) == 0 {
+		return summary, nil
+	}
+
+	var err error
+	summary.Responses, err = max.PurgeFiles(zone, files)
+	for _, rsp := range summary.Responses {
+		if rsp != nil {
+			summary.Purged++
+		}
+	}
+	return summary, err
+}
+
+func (rw PathRewrite) rewriteAll(files []string) []string {
+	if len(files) == 0 {
+		return nil
+	}
+	out := make([]string, len(files))
+	for i, file := range files {
+		out[i] = rw.Rewrite(file)
+	}
+	return out
+}
+
+func hashFile(filename string) (string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
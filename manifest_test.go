@@ -0,0 +1,101 @@
+package maxcdn
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadManifest(t *testing.T) {
+	assert := assert.New(t)
+
+	m, err := ReadManifest(strings.NewReader(`{"app.js":"abc","css/app.css":"def"}`))
+	assert.Nil(err)
+	assert.Equal(Manifest{"app.js": "abc", "css/app.css": "def"}, m)
+
+	_, err = ReadManifest(strings.NewReader(`[]`))
+	assert.NotNil(err)
+}
+
+func TestWalkManifest(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "maxcdn-manifest")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+
+	assert.Nil(os.MkdirAll(filepath.Join(dir, "css"), 0755))
+	assert.Nil(ioutil.WriteFile(filepath.Join(dir, "app.js"), []byte("js"), 0644))
+	assert.Nil(ioutil.WriteFile(filepath.Join(dir, "css", "app.css"), []byte("css"), 0644))
+
+	m, err := WalkManifest(dir)
+	assert.Nil(err)
+	assert.Equal(Manifest{
+		"app.js":      "16cedf80ade01c62bdd1ae931d0492330c0b62bf294c08c095ce2fab21a9298d",
+		"css/app.css": "36e64f19f57a05c8cd5b6bf7eff72703b4bbab19def7832eb4e686e7fa482eef",
+	}, m)
+}
+
+func TestDiffManifests(t *testing.T) {
+	assert := assert.New(t)
+
+	from := Manifest{"a.js": "1", "b.js": "1", "c.js": "1"}
+	to := Manifest{"a.js": "1", "b.js": "2", "d.js": "1"}
+
+	diff := DiffManifests(from, to)
+	assert.Equal([]string{"d.js"}, diff.Added)
+	assert.Equal([]string{"b.js"}, diff.Changed)
+	assert.Equal([]string{"c.js"}, diff.Removed)
+	assert.Equal([]string{"b.js", "c.js", "d.js"}, diff.Files())
+}
+
+func TestPathRewrite_Rewrite(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("/app.js", PathRewrite{}.Rewrite("app.js"))
+	assert.Equal("/static/v2/app.js", PathRewrite{To: "/static/v2"}.Rewrite("app.js"))
+	assert.Equal("/static/v2/app.js", PathRewrite{From: "dist/", To: "static/v2/"}.Rewrite("dist/app.js"))
+	assert.Equal("/other/app.js", PathRewrite{From: "dist/", To: "static/v2/"}.Rewrite("other/app.js"))
+	assert.Equal("/static/app.js", PathRewrite{From: "dist", To: "/static"}.Rewrite("dist/app.js"))
+	assert.Equal("/static", PathRewrite{From: "dist", To: "/static"}.Rewrite("dist"))
+	assert.Equal("/distribution.js", PathRewrite{From: "dist", To: "/static"}.Rewrite("distribution.js"))
+	assert.Equal("/dist-old/app.js", PathRewrite{From: "dist/", To: "/static"}.Rewrite("dist-old/app.js"))
+}
+
+func TestMaxCDN_PurgeManifestDiff(t *testing.T) {
+	assert := assert.New(t)
+	max := NewMaxCDN("alias", "token", "secret")
+
+	var (
+		mu     sync.Mutex
+		purged []string
+	)
+	max.HTTPClient = stubHTTPFunc(func(r *http.Request) (*http.Response, error) {
+		mu.Lock()
+		purged = append(purged, r.URL.Query().Get("files"))
+		mu.Unlock()
+		return stubJSONResponse(r, 200, "delete.json"), nil
+	})
+
+	from := Manifest{"a.js": "1", "b.js": "1", "c.js": "1"}
+	to := Manifest{"a.js": "1", "b.js": "2", "d.js": "1"}
+
+	summary, err := max.PurgeManifestDiff(123456, from, to, PathRewrite{To: "/static"})
+	assert.Nil(err)
+	assert.Equal([]string{"/static/d.js"}, summary.Added)
+	assert.Equal([]string{"/static/b.js"}, summary.Changed)
+	assert.Equal([]string{"/static/c.js"}, summary.Removed)
+	assert.Equal(3, summary.Purged)
+	assert.ElementsMatch([]string{"/static/b.js", "/static/c.js", "/static/d.js"}, purged)
+
+	summary, err = max.PurgeManifestDiff(123456, from, from, PathRewrite{})
+	assert.Nil(err)
+	assert.Equal(0, summary.Purged)
+	assert.Len(purged, 3)
+}
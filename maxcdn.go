@@ -36,6 +36,11 @@ type MaxCDN struct {
 	Verbose    bool
 	client     oauth.Client
 	HTTPClient *http.Client
+
+	// Journal records every purge call when set. See WithActor.
+	Journal *Journal
+	actor   string
+	reason  string
 }
 
 // NewMaxCDN sets up a new MaxCDN instance.
@@ -99,12 +104,14 @@ func (max *MaxCDN) Delete(endpoint string, form url.Values) (*Response, error) {
 
 // PurgeZone purges a specified zones cache.
 func (max *MaxCDN) PurgeZone(zone int) (*Response, error) {
-	return max.Delete(fmt.Sprintf("/zones/pull.json/%d/cache", zone), nil)
+	return max.PurgeZoneString(strconv.FormatInt(int64(zone), 10))
 }
 
 // PurgeZoneString purges a specified zones cache.
 func (max *MaxCDN) PurgeZoneString(zone string) (*Response, error) {
-	return max.Delete(fmt.Sprintf("/zones/pull.json/%s/cache", zone), nil)
+	rsp, err := max.Delete(fmt.Sprintf("/zones/pull.json/%s/cache", zone), nil)
+	max.journal(JournalPurgeZone, zone, nil, rsp, err)
+	return rsp, err
 }
 
 // PurgeZonesString purges multiple zones caches.
@@ -159,7 +166,9 @@ func (max *MaxCDN) PurgeFileString(zone string, file string) (*Response, error)
 	form := url.Values{}
 	form.Set("files", file)
 
-	return max.Delete("/zones/pull.json/"+zone+"/cache", form)
+	rsp, err := max.Delete("/zones/pull.json/"+zone+"/cache", form)
+	max.journal(JournalPurgeFile, zone, []string{file}, rsp, err)
+	return rsp, err
 }
 
 // PurgeFiles purges multiple files from a zone.
@@ -227,7 +236,7 @@ func (max *MaxCDN) Do(method, endpoint string, form url.Values) (*Response, erro
 	}
 
 	if rsp.Code > 299 {
-		return nil, fmt.Errorf("%s: %s", rsp.Error.Type, rsp.Error.Message)
+		return nil, &APIError{Code: rsp.Code, Type: rsp.Error.Type, Message: rsp.Error.Message}
 	}
 
 	return rsp, nil
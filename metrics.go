@@ -0,0 +1,266 @@
+package maxcdn
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// StatsMetric names a StatsSummary counter, using its JSON field name.
+type StatsMetric string
+
+// Stats metrics.
+const (
+	MetricHits         StatsMetric = "hits"
+	MetricCacheHits    StatsMetric = "cache_hits"
+	MetricNonCacheHits StatsMetric = "noncache_hits"
+	MetricBytes        StatsMetric = "bytes"
+)
+
+// StatsMetrics lists every StatsMetric, in StatsSummary field order.
+var StatsMetrics = []StatsMetric{MetricHits, MetricCacheHits, MetricNonCacheHits, MetricBytes}
+
+// Value returns the counter for m, or zero for an unknown metric.
+func (s StatsSummary) Value(m StatsMetric) int64 {
+	switch m {
+	case MetricHits:
+		return s.Hits
+	case MetricCacheHits:
+		return s.CacheHits
+	case MetricNonCacheHits:
+		return s.NonCacheHits
+	case MetricBytes:
+		return s.Bytes
+	}
+	return 0
+}
+
+// CacheHitRatio returns the fraction of hits served from cache.
+func (s StatsSummary) CacheHitRatio() float64 {
+	return ratio(s.CacheHits, s.Hits)
+}
+
+// OriginOffload returns the fraction of hits which didn't reach the origin.
+func (s StatsSummary) OriginOffload() float64 {
+	if s.Hits == 0 {
+		return 0
+	}
+	return 1 - ratio(s.NonCacheHits, s.Hits)
+}
+
+// AvgObjectSize returns the average bytes served per hit.
+func (s StatsSummary) AvgObjectSize() float64 {
+	return ratio(s.Bytes, s.Hits)
+}
+
+// BitsPerSecond returns the average bandwidth of p over its interval.
+func (p StatsPoint) BitsPerSecond(interval StatsInterval) float64 {
+	d := interval.Duration(p.Timestamp)
+	if d <= 0 {
+		return 0
+	}
+	return float64(p.Bytes) * 8 / d.Seconds()
+}
+
+// Duration returns the length of the interval starting at start. Daily and
+// monthly intervals follow the calendar of start's location.
+func (i StatsInterval) Duration(start time.Time) time.Duration {
+	switch i {
+	case StatsHourly:
+		return time.Hour
+	case StatsDaily:
+		return start.AddDate(0, 0, 1).Sub(start)
+	case StatsMonthly:
+		return start.AddDate(0, 1, 0).Sub(start)
+	}
+	return 0
+}
+
+// Truncate returns the start of the interval containing t, in t's location.
+func (i StatsInterval) Truncate(t time.Time) time.Time {
+	y, m, d := t.Date()
+	switch i {
+	case StatsHourly:
+		return time.Date(y, m, d, t.Hour(), 0, 0, 0, t.Location())
+	case StatsDaily:
+		return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+	case StatsMonthly:
+		return time.Date(y, m, 1, 0, 0, 0, 0, t.Location())
+	}
+	return t
+}
+
+// MetricChange is the change of a single metric between two periods.
+// Percent is +Inf when the metric grew from zero.
+type MetricChange struct {
+	Metric   StatsMetric `json:"metric"`
+	Previous int64       `json:"previous"`
+	Current  int64       `json:"current"`
+	Delta    int64       `json:"delta"`
+	Percent  float64     `json:"percent"`
+}
+
+// Changes returns the change of every metric from prev to cur.
+func Changes(prev, cur StatsSummary) []MetricChange {
+	changes := make([]MetricChange, len(StatsMetrics))
+	for i, m := range StatsMetrics {
+		changes[i] = change(m, prev.Value(m), cur.Value(m))
+	}
+	return changes
+}
+
+func change(m StatsMetric, prev, cur int64) MetricChange {
+	c := MetricChange{Metric: m, Previous: prev, Current: cur, Delta: cur - prev}
+	switch {
+	case prev != 0:
+		c.Percent = float64(c.Delta) / math.Abs(float64(prev)) * 100
+	case cur != 0:
+		c.Percent = math.Inf(1)
+	}
+	return c
+}
+
+// StatsDelta holds the changes of a point from the point before it.
+type StatsDelta struct {
+	Timestamp time.Time
+	Changes   []MetricChange
+}
+
+// Deltas returns the period-over-period change of every point but the
+// first.
+func (series StatsSeries) Deltas() []StatsDelta {
+	if len(series) < 2 {
+		return nil
+	}
+
+	deltas := make([]StatsDelta, 0, len(series)-1)
+	for i := 1; i < len(series); i++ {
+		deltas = append(deltas, StatsDelta{
+			Timestamp: series[i].Timestamp,
+			Changes:   Changes(series[i-1].StatsSummary, series[i].StatsSummary),
+		})
+	}
+	return deltas
+}
+
+// Bandwidth returns the average bits per second of every point.
+func (series StatsSeries) Bandwidth(interval StatsInterval) []float64 {
+	bps := make([]float64, len(series))
+	for i, p := range series {
+		bps[i] = p.BitsPerSecond(interval)
+	}
+	return bps
+}
+
+// MergeStatsSeries sums series, such as those of several zones, point by
+// point. Points are matched by timestamp and the result is sorted by time.
+func MergeStatsSeries(series ...StatsSeries) StatsSeries {
+	var merged StatsSeries
+	index := map[int64]int{}
+
+	for _, s := range series {
+		for _, p := range s {
+			key := p.Timestamp.UnixNano()
+			if i, ok := index[key]; ok {
+				merged[i].StatsSummary = merged[i].StatsSummary.Add(p.StatsSummary)
+				continue
+			}
+			index[key] = len(merged)
+			merged = append(merged, p)
+		}
+	}
+
+	merged.sort()
+	return merged
+}
+
+// Resample converts a series from one interval to another. Going to a
+// coarser interval sums the points within it, going to a finer one spreads
+// every point evenly over the intervals it covers, with any remainder added
+// to the first. The result is sorted by time, and is left unchanged for
+// unknown intervals.
+func (series StatsSeries) Resample(from, to StatsInterval) StatsSeries {
+	if len(series) == 0 || from == to || intervalRank(from) == 0 || intervalRank(to) == 0 {
+		out := append(StatsSeries(nil), series...)
+		out.sort()
+		return out
+	}
+
+	if intervalRank(to) > intervalRank(from) {
+		var out StatsSeries
+		index := map[int64]int{}
+
+		for _, p := range series {
+			start := to.Truncate(p.Timestamp)
+			key := start.UnixNano()
+			if i, ok := index[key]; ok {
+				out[i].StatsSummary = out[i].StatsSummary.Add(p.StatsSummary)
+				continue
+			}
+			index[key] = len(out)
+			out = append(out, StatsPoint{Timestamp: start, StatsSummary: p.StatsSummary})
+		}
+
+		out.sort()
+		return out
+	}
+
+	var out StatsSeries
+	for _, p := range series {
+		var starts []time.Time
+		end := p.Timestamp.Add(from.Duration(p.Timestamp))
+		for t := p.Timestamp; t.Before(end); t = t.Add(to.Duration(t)) {
+			starts = append(starts, t)
+		}
+
+		n := int64(len(starts))
+		for i, start := range starts {
+			s := spread(p.StatsSummary, n, i == 0)
+			out = append(out, StatsPoint{Timestamp: start, StatsSummary: s})
+		}
+	}
+
+	out.sort()
+	return out
+}
+
+func (series StatsSeries) sort() {
+	sort.SliceStable(series, func(i, j int) bool {
+		return series[i].Timestamp.Before(series[j].Timestamp)
+	})
+}
+
+// spread returns a 1/n share of s, plus the remainders when first is set.
+func spread(s StatsSummary, n int64, first bool) StatsSummary {
+	share := func(v int64) int64 {
+		if first {
+			return v/n + v%n
+		}
+		return v / n
+	}
+	return StatsSummary{
+		Hits:         share(s.Hits),
+		CacheHits:    share(s.CacheHits),
+		NonCacheHits: share(s.NonCacheHits),
+		Bytes:        share(s.Bytes),
+	}
+}
+
+func intervalRank(i StatsInterval) int {
+	switch i {
+	case StatsHourly:
+		return 1
+	case StatsDaily:
+		return 2
+	case StatsMonthly:
+		return 3
+	}
+	return 0
+}
+
+func ratio(n, d int64) float64 {
+	if d == 0 {
+		return 0
+	}
+	return float64(n) / float64(d)
+}
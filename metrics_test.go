@@ -0,0 +1,145 @@
+package maxcdn
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func day(d int) time.Time {
+	return time.Date(2014, 5, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestStatsSummary_metrics(t *testing.T) {
+	assert := assert.New(t)
+
+	s := StatsSummary{Hits: 200, CacheHits: 150, NonCacheHits: 50, Bytes: 1000}
+	assert.Equal(0.75, s.CacheHitRatio())
+	assert.Equal(0.75, s.OriginOffload())
+	assert.Equal(5.0, s.AvgObjectSize())
+	assert.Equal(int64(50), s.Value(MetricNonCacheHits))
+	assert.Equal(int64(0), s.Value("unknown"))
+
+	var zero StatsSummary
+	assert.Equal(0.0, zero.CacheHitRatio())
+	assert.Equal(0.0, zero.OriginOffload())
+	assert.Equal(0.0, zero.AvgObjectSize())
+}
+
+func TestStatsPoint_BitsPerSecond(t *testing.T) {
+	assert := assert.New(t)
+
+	p := StatsPoint{Timestamp: day(18), StatsSummary: StatsSummary{Bytes: 10800}}
+	assert.Equal(24.0, p.BitsPerSecond(StatsHourly))
+	assert.Equal(1.0, p.BitsPerSecond(StatsDaily))
+	assert.Equal(0.0, p.BitsPerSecond(""))
+
+	series := StatsSeries{p, p}
+	assert.Equal([]float64{1, 1}, series.Bandwidth(StatsDaily))
+}
+
+func TestStatsInterval_Duration(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(time.Hour, StatsHourly.Duration(day(1)))
+	assert.Equal(24*time.Hour, StatsDaily.Duration(day(1)))
+	assert.Equal(31*24*time.Hour, StatsMonthly.Duration(day(1)))
+
+	ny, err := time.LoadLocation("America/New_York")
+	if err == nil {
+		dst := time.Date(2014, 3, 9, 0, 0, 0, 0, ny)
+		assert.Equal(23*time.Hour, StatsDaily.Duration(dst))
+	}
+}
+
+func TestStatsInterval_Truncate(t *testing.T) {
+	assert := assert.New(t)
+
+	ts := time.Date(2014, 5, 18, 13, 45, 10, 0, time.UTC)
+	assert.Equal(time.Date(2014, 5, 18, 13, 0, 0, 0, time.UTC), StatsHourly.Truncate(ts))
+	assert.Equal(day(18), StatsDaily.Truncate(ts))
+	assert.Equal(day(1), StatsMonthly.Truncate(ts))
+}
+
+func TestStatsSeries_Deltas(t *testing.T) {
+	assert := assert.New(t)
+
+	series := StatsSeries{
+		{Timestamp: day(18), StatsSummary: StatsSummary{Hits: 100, CacheHits: 0}},
+		{Timestamp: day(19), StatsSummary: StatsSummary{Hits: 150, CacheHits: 10}},
+	}
+
+	deltas := series.Deltas()
+	assert.Len(deltas, 1)
+	assert.Equal(day(19), deltas[0].Timestamp)
+	assert.Equal(MetricChange{Metric: MetricHits, Previous: 100, Current: 150, Delta: 50, Percent: 50}, deltas[0].Changes[0])
+	assert.True(math.IsInf(deltas[0].Changes[1].Percent, 1))
+	assert.Equal(0.0, deltas[0].Changes[2].Percent)
+
+	assert.Nil(series[:1].Deltas())
+}
+
+func TestMergeStatsSeries(t *testing.T) {
+	assert := assert.New(t)
+
+	a := StatsSeries{
+		{Timestamp: day(18), StatsSummary: StatsSummary{Hits: 1}},
+		{Timestamp: day(19), StatsSummary: StatsSummary{Hits: 2}},
+	}
+	b := StatsSeries{
+		{Timestamp: day(20), StatsSummary: StatsSummary{Hits: 4}},
+		{Timestamp: day(19), StatsSummary: StatsSummary{Hits: 8, Bytes: 1}},
+	}
+
+	merged := MergeStatsSeries(a, b)
+	assert.Equal(StatsSeries{
+		{Timestamp: day(18), StatsSummary: StatsSummary{Hits: 1}},
+		{Timestamp: day(19), StatsSummary: StatsSummary{Hits: 10, Bytes: 1}},
+		{Timestamp: day(20), StatsSummary: StatsSummary{Hits: 4}},
+	}, merged)
+
+	// inputs are left untouched
+	assert.Equal(int64(2), a[1].Hits)
+}
+
+func TestStatsSeries_Resample(t *testing.T) {
+	assert := assert.New(t)
+
+	hourly := StatsSeries{
+		{Timestamp: time.Date(2014, 5, 18, 23, 0, 0, 0, time.UTC), StatsSummary: StatsSummary{Hits: 1}},
+		{Timestamp: time.Date(2014, 5, 18, 1, 0, 0, 0, time.UTC), StatsSummary: StatsSummary{Hits: 2}},
+		{Timestamp: time.Date(2014, 5, 19, 0, 0, 0, 0, time.UTC), StatsSummary: StatsSummary{Hits: 4}},
+	}
+	assert.Equal(StatsSeries{
+		{Timestamp: day(18), StatsSummary: StatsSummary{Hits: 3}},
+		{Timestamp: day(19), StatsSummary: StatsSummary{Hits: 4}},
+	}, hourly.Resample(StatsHourly, StatsDaily))
+
+	daily := StatsSeries{{Timestamp: day(18), StatsSummary: StatsSummary{Hits: 50, Bytes: 24}}}
+	resampled := daily.Resample(StatsDaily, StatsHourly)
+	assert.Len(resampled, 24)
+	assert.Equal(StatsSummary{Hits: 4, Bytes: 1}, resampled[0].StatsSummary)
+	assert.Equal(StatsSummary{Hits: 2, Bytes: 1}, resampled[23].StatsSummary)
+	assert.Equal(time.Date(2014, 5, 18, 23, 0, 0, 0, time.UTC), resampled[23].Timestamp)
+	assert.Equal(daily.Summary(), resampled.Summary())
+
+	assert.Equal(daily, daily.Resample(StatsDaily, ""))
+}
+
+func TestStatsSeries_fixture(t *testing.T) {
+	assert := assert.New(t)
+	max := NewMaxCDN("alias", "token", "secret")
+	max.HTTPClient = stubHTTPOk()
+
+	stats, err := max.GetStats(StatsQuery{Interval: StatsDaily})
+	assert.Nil(err)
+
+	assert.InDelta(0.6211, stats.Summary.CacheHitRatio(), 0.0001)
+	assert.InDelta(1096.57, stats.Summary.AvgObjectSize(), 0.01)
+
+	monthly := stats.Series.Resample(StatsDaily, StatsMonthly)
+	assert.Len(monthly, 2)
+	assert.Equal(stats.Summary, monthly.Summary())
+}
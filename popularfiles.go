@@ -0,0 +1,97 @@
+package maxcdn
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// PopularFilesQuery selects a popular files report. Its Interval is ignored.
+type PopularFilesQuery struct {
+	StatsQuery
+
+	// Top limits the report to the N most requested files. Zero returns
+	// every file.
+	Top int
+}
+
+// PopularFile holds the traffic counters of a single file.
+type PopularFile struct {
+	URI   string `json:"uri"`
+	Hits  int64  `json:"hits"`
+	Bytes int64  `json:"bytes"`
+}
+
+// PopularFilesSummary holds the totals of a popular files report. The API
+// sends null totals for empty reports, which are decoded as zero.
+type PopularFilesSummary struct {
+	Hits  int64 `json:"hits"`
+	Bytes int64 `json:"bytes"`
+}
+
+// PopularFilesReport is a typed popular files report.
+type PopularFilesReport struct {
+
+	// Files is sorted by hits, most requested first.
+	Files   []PopularFile
+	Summary PopularFilesSummary
+
+	Page  int
+	Pages int
+	Total int
+}
+
+// PopularFiles fetches a popular files report.
+func (max *MaxCDN) PopularFiles(q PopularFilesQuery) (*PopularFilesReport, error) {
+	sq := q.StatsQuery
+	sq.Interval = ""
+	if q.Top > 0 && sq.PageSize == 0 {
+		sq.PageSize = q.Top
+	}
+
+	report := &PopularFilesReport{}
+	err := max.eachReportPage(sq, "popularfiles.json", func(data json.RawMessage, page reportPage) error {
+		var raw struct {
+			PopularFiles []struct {
+				URI  string    `json:"uri"`
+				Hit  jsonInt64 `json:"hit"`
+				Size jsonInt64 `json:"size"`
+			} `json:"popularfiles"`
+			Summary struct {
+				Hit  jsonInt64 `json:"hit"`
+				Size jsonInt64 `json:"size"`
+			} `json:"summary"`
+		}
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return err
+		}
+
+		for _, file := range raw.PopularFiles {
+			report.Files = append(report.Files, PopularFile{
+				URI:   file.URI,
+				Hits:  int64(file.Hit),
+				Bytes: int64(file.Size),
+			})
+		}
+		report.Summary = PopularFilesSummary{
+			Hits:  int64(raw.Summary.Hit),
+			Bytes: int64(raw.Summary.Size),
+		}
+		report.Page, report.Pages, report.Total = page.Page, page.Pages, int(page.Total)
+
+		if q.Top > 0 && len(report.Files) >= q.Top {
+			return errStopPaging
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(report.Files, func(i, j int) bool {
+		return report.Files[i].Hits > report.Files[j].Hits
+	})
+	if q.Top > 0 && len(report.Files) > q.Top {
+		report.Files = report.Files[:q.Top]
+	}
+	return report, nil
+}
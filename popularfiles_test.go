@@ -0,0 +1,60 @@
+package maxcdn
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaxCDN_PopularFiles(t *testing.T) {
+	assert := assert.New(t)
+	max := NewMaxCDN("alias", "token", "secret")
+
+	var recorder http.Response
+	max.HTTPClient = stubHTTPOkRecorded(&recorder)
+
+	report, err := max.PopularFiles(PopularFilesQuery{StatsQuery: StatsQuery{Zone: 123456, Interval: StatsDaily}})
+	assert.Nil(err)
+	assert.Equal([]PopularFile{{URI: "/master.css"}, {URI: "/master.js"}}, report.Files)
+	assert.Equal(PopularFilesSummary{}, report.Summary)
+
+	assert.Equal("/alias/reports/123456/popularfiles.json", recorder.Request.URL.Path)
+}
+
+func TestMaxCDN_PopularFiles_top(t *testing.T) {
+	assert := assert.New(t)
+	max := NewMaxCDN("alias", "token", "secret")
+
+	var queries []string
+	max.HTTPClient = stubHTTPFunc(func(r *http.Request) (*http.Response, error) {
+		queries = append(queries, r.URL.RawQuery)
+
+		body := `{"code":200,"data":{"page":1,"pages":2,"total":"4","summary":{"hit":"60","size":"600"},"popularfiles":[
+			{"uri":"/b.css","hit":"10","size":"100"},{"uri":"/a.css","hit":"30","size":"300"}]}}`
+		if r.URL.Query().Get("page") == "2" {
+			body = `{"code":200,"data":{"page":2,"pages":2,"total":"4","summary":{"hit":"60","size":"600"},"popularfiles":[
+				{"uri":"/c.css","hit":"15","size":"150"},{"uri":"/d.css","hit":"5","size":"50"}]}}`
+		}
+		return stubBodyResponse(r, 200, body), nil
+	})
+
+	report, err := max.PopularFiles(PopularFilesQuery{Top: 2})
+	assert.Nil(err)
+	assert.Equal([]string{"page_size=2"}, queries)
+	assert.Equal([]PopularFile{
+		{URI: "/a.css", Hits: 30, Bytes: 300},
+		{URI: "/b.css", Hits: 10, Bytes: 100},
+	}, report.Files)
+	assert.Equal(PopularFilesSummary{Hits: 60, Bytes: 600}, report.Summary)
+	assert.Equal(4, report.Total)
+
+	queries = nil
+	report, err = max.PopularFiles(PopularFilesQuery{})
+	assert.Nil(err)
+	assert.Equal([]string{"", "page=2"}, queries)
+	assert.Len(report.Files, 4)
+	assert.Equal("/a.css", report.Files[0].URI)
+	assert.Equal("/c.css", report.Files[1].URI)
+	assert.Equal("/d.css", report.Files[3].URI)
+}
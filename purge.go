@@ -0,0 +1,219 @@
+package maxcdn
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PurgeOptions controls how PurgeDirectoryOptions and PurgePattern find the
+// files to purge.
+type PurgeOptions struct {
+
+	// Manifest is a caller supplied list of file paths to match against.
+	// When empty, the zone's popular files report is used instead.
+	Manifest []string
+
+	// DryRun lists the matching files without purging anything.
+	DryRun bool
+
+	// Expand skips the API's directory purge and always matches files
+	// client-side.
+	Expand bool
+}
+
+// PurgeResult is returned by the directory and pattern purge helpers.
+type PurgeResult struct {
+
+	// Files holds the purged files, or the files that would have been
+	// purged when DryRun is set. When the API purged a whole directory it
+	// holds the directory only.
+	Files []string
+
+	// Directory is true when the API purged the directory in a single call.
+	Directory bool
+
+	// Fallback is true when the API rejected the directory purge and the
+	// directory was expanded client-side instead. Files then only holds
+	// the files listed in the manifest or popular files report, so others
+	// below the directory may still be cached.
+	Fallback  bool
+	DryRun    bool
+	Responses []*Response
+}
+
+// PurgeDirectory purges every file below prefix from a zone. See
+// PurgeDirectoryOptions.
+func (max *MaxCDN) PurgeDirectory(zone int, prefix string) (*PurgeResult, error) {
+	return max.PurgeDirectoryOptions(zone, prefix, PurgeOptions{})
+}
+
+// PurgeDirectoryOptions purges every file below prefix from a zone.
+//
+// It uses the API's directory purge first. If the API rejects the purge as
+// unsupported, or when opts.Expand or opts.DryRun are set, the directory is
+// expanded client-side from opts.Manifest or the zone's popular files report
+// and the matching files are purged with PurgeFiles. Other errors of the
+// directory purge are returned.
+func (max *MaxCDN) PurgeDirectoryOptions(zone int, prefix string, opts PurgeOptions) (*PurgeResult, error) {
+	dir := normalizeDirectory(prefix)
+
+	if opts.Expand || opts.DryRun {
+		return max.purgeMatching(zone, dir+"**", opts)
+	}
+
+	rsp, err := max.purgeDirectory(zone, dir)
+	if err == nil {
+		return &PurgeResult{
+			Files:     []string{dir},
+			Directory: true,
+			Responses: []*Response{rsp},
+		}, nil
+	}
+	if !isUnsupportedPurge(err) {
+		return nil, err
+	}
+
+	result, err := max.purgeMatching(zone, dir+"**", opts)
+	if result != nil {
+		result.Fallback = true
+	}
+	return result, err
+}
+
+// isUnsupportedPurge reports whether the API rejected a purge request
+// itself, as it does for directory and wildcard purges it doesn't support,
+// rather than failing for reasons such as authentication.
+func isUnsupportedPurge(err error) bool {
+	apiErr, ok := err.(*APIError)
+	return ok && (apiErr.Code == 400 || apiErr.Code == 422)
+}
+
+// PurgePattern purges every file in a zone matching pattern.
+//
+// Patterns are matched against the full file path. "*" matches any run of
+// characters other than "/", "**" matches any run of characters including
+// "/", and "?" matches a single character other than "/". A pattern ending
+// in "/**" with no other wildcards is handed to PurgeDirectoryOptions.
+// All other patterns are expanded client-side, like PurgeDirectoryOptions.
+func (max *MaxCDN) PurgePattern(zone int, pattern string, opts PurgeOptions) (*PurgeResult, error) {
+	if dir := strings.TrimSuffix(pattern, "**"); dir != pattern &&
+		strings.HasSuffix(dir, "/") && !strings.ContainsAny(dir, "*?") {
+		return max.PurgeDirectoryOptions(zone, dir, opts)
+	}
+
+	return max.purgeMatching(zone, pattern, opts)
+}
+
+// ExpandPattern returns the files matching pattern, taken from manifest or,
+// when manifest is empty, from the zone's popular files report. See
+// PurgePattern for the pattern syntax.
+func (max *MaxCDN) ExpandPattern(zone int, pattern string, manifest []string) ([]string, error) {
+	re, err := compilePurgePattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	files := manifest
+	if len(files) == 0 {
+		if files, err = max.popularFileURIs(zone); err != nil {
+			return nil, err
+		}
+	}
+
+	var (
+		matched []string
+		seen    = map[string]bool{}
+	)
+	for _, file := range files {
+		file = normalizeFile(file)
+		if !seen[file] && re.MatchString(file) {
+			seen[file] = true
+			matched = append(matched, file)
+		}
+	}
+	sort.Strings(matched)
+	return matched, nil
+}
+
+func (max *MaxCDN) purgeMatching(zone int, pattern string, opts PurgeOptions) (*PurgeResult, error) {
+	files, err := max.ExpandPattern(zone, pattern, opts.Manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PurgeResult{Files: files, DryRun: opts.DryRun}
+	if opts.DryRun || len(files) == 0 {
+		return result, nil
+	}
+
+	result.Responses, err = max.PurgeFiles(zone, files)
+	return result, err
+}
+
+// purgeDirectory asks the API to purge everything below dir.
+func (max *MaxCDN) purgeDirectory(zone int, dir string) (*Response, error) {
+	form := url.Values{}
+	form.Set("files", dir+"*")
+
+	zoneID := strconv.Itoa(zone)
+	rsp, err := max.Delete("/zones/pull.json/"+zoneID+"/cache", form)
+	max.journal(JournalPurgeDirectory, zoneID, []string{dir}, rsp, err)
+	return rsp, err
+}
+
+// popularFileURIs collects the file URIs from every page of a zone's popular
+// files report.
+func (max *MaxCDN) popularFileURIs(zone int) ([]string, error) {
+	report, err := max.PopularFiles(PopularFilesQuery{StatsQuery: StatsQuery{Zone: zone}})
+	if err != nil {
+		return nil, err
+	}
+
+	uris := make([]string, len(report.Files))
+	for i, file := range report.Files {
+		uris[i] = file.URI
+	}
+	return uris, nil
+}
+
+func compilePurgePattern(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, errors.New("maxcdn: empty purge pattern")
+	}
+	pattern = normalizeFile(pattern)
+
+	expr := "^"
+	for i := 0; i < len(pattern); i++ {
+		switch {
+		case strings.HasPrefix(pattern[i:], "**"):
+			expr += ".*"
+			i++
+		case pattern[i] == '*':
+			expr += "[^/]*"
+		case pattern[i] == '?':
+			expr += "[^/]"
+		default:
+			expr += regexp.QuoteMeta(pattern[i : i+1])
+		}
+	}
+	return regexp.Compile(expr + "$")
+}
+
+func normalizeFile(file string) string {
+	if !strings.HasPrefix(file, "/") {
+		file = "/" + file
+	}
+	return file
+}
+
+func normalizeDirectory(dir string) string {
+	dir = normalizeFile(dir)
+	if !strings.HasSuffix(dir, "/") {
+		dir += "/"
+	}
+	return dir
+}
@@ -0,0 +1,141 @@
+package maxcdn
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaxCDN_PurgeDirectory(t *testing.T) {
+	assert := assert.New(t)
+	max := NewMaxCDN("alias", "token", "secret")
+
+	var recorder http.Response
+	max.HTTPClient = stubHTTPOkRecorded(&recorder)
+
+	res, err := max.PurgeDirectory(123456, "static/v2")
+	assert.Nil(err)
+	assert.NotNil(res)
+	assert.True(res.Directory)
+	assert.Equal([]string{"/static/v2/"}, res.Files)
+	assert.Len(res.Responses, 1)
+
+	assert.Equal("DELETE", recorder.Request.Method)
+	assert.Equal("/alias/zones/pull.json/123456/cache", recorder.Request.URL.Path)
+	assert.Equal("/static/v2/*", recorder.Request.URL.Query().Get("files"))
+}
+
+func TestMaxCDN_PurgeDirectory_fallback(t *testing.T) {
+	assert := assert.New(t)
+	max := NewMaxCDN("alias", "token", "secret")
+
+	var (
+		mu     sync.Mutex
+		purged []string
+	)
+	max.HTTPClient = stubHTTPFunc(func(r *http.Request) (*http.Response, error) {
+		files := r.URL.Query().Get("files")
+		switch {
+		case r.Method == "DELETE" && strings.HasSuffix(files, "*"):
+			return stubBodyResponse(r, 400, stubAPIError), nil
+		case r.Method == "DELETE":
+			mu.Lock()
+			purged = append(purged, files)
+			mu.Unlock()
+			return stubJSONResponse(r, 200, "delete.json"), nil
+		default:
+			assert.Equal("/alias/reports/123456/popularfiles.json", r.URL.Path)
+			return stubJSONResponse(r, 200, "popularfiles.json"), nil
+		}
+	})
+
+	res, err := max.PurgeDirectory(123456, "/")
+	assert.Nil(err)
+	assert.False(res.Directory)
+	assert.True(res.Fallback)
+	assert.Equal([]string{"/master.css", "/master.js"}, res.Files)
+	assert.Len(res.Responses, 2)
+	assert.ElementsMatch([]string{"/master.css", "/master.js"}, purged)
+}
+
+func TestMaxCDN_PurgeDirectory_errors(t *testing.T) {
+	assert := assert.New(t)
+	max := NewMaxCDN("alias", "token", "secret")
+
+	max.HTTPClient = stubHTTPFunc(func(r *http.Request) (*http.Response, error) {
+		assert.Equal("DELETE", r.Method)
+		return stubBodyResponse(r, 401, `{"code":401,"error":{"type":"unauthorized","message":"Invalid OAuth"}}`), nil
+	})
+	res, err := max.PurgeDirectory(123456, "/static/")
+	assert.Nil(res)
+	if assert.NotNil(err) {
+		assert.Equal(401, err.(*APIError).Code)
+		assert.Equal("unauthorized: Invalid OAuth", err.Error())
+	}
+
+	max.HTTPClient = stubHTTPFunc(func(r *http.Request) (*http.Response, error) {
+		return nil, errors.New("connection refused")
+	})
+	res, err = max.PurgeDirectory(123456, "/static/")
+	assert.Nil(res)
+	assert.NotNil(err)
+}
+
+func TestMaxCDN_PurgePattern(t *testing.T) {
+	assert := assert.New(t)
+	max := NewMaxCDN("alias", "token", "secret")
+
+	var recorder http.Response
+	max.HTTPClient = stubHTTPOkRecorded(&recorder)
+
+	manifest := []string{"static/v2/app.js", "/static/v2/app.css", "/static/v2/img/logo.png", "/index.html"}
+	res, err := max.PurgePattern(123456, "/static/v2/*.js", PurgeOptions{Manifest: manifest})
+	assert.Nil(err)
+	assert.Equal([]string{"/static/v2/app.js"}, res.Files)
+	assert.Len(res.Responses, 1)
+	assert.Equal("/static/v2/app.js", recorder.Request.URL.Query().Get("files"))
+}
+
+func TestMaxCDN_PurgePattern_dryRun(t *testing.T) {
+	assert := assert.New(t)
+	max := NewMaxCDN("alias", "token", "secret")
+	max.HTTPClient = stubHTTPFunc(func(r *http.Request) (*http.Response, error) {
+		t.Errorf("unexpected request: %s %s", r.Method, r.URL)
+		return stubJSONResponse(r, 200, "delete.json"), nil
+	})
+
+	manifest := []string{"/static/v2/app.js", "/static/v2/img/logo.png", "/static/v1/app.js"}
+	res, err := max.PurgePattern(123456, "/static/v2/**", PurgeOptions{Manifest: manifest, DryRun: true})
+	assert.Nil(err)
+	assert.True(res.DryRun)
+	assert.False(res.Directory)
+	assert.Equal([]string{"/static/v2/app.js", "/static/v2/img/logo.png"}, res.Files)
+	assert.Empty(res.Responses)
+}
+
+func Test_compilePurgePattern(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, tc := range []struct {
+		pattern, file string
+		match         bool
+	}{
+		{"/static/*.css", "/static/app.css", true},
+		{"/static/*.css", "/static/css/app.css", false},
+		{"/static/**.css", "/static/css/app.css", true},
+		{"static/app.?s", "/static/app.js", true},
+		{"/static/app.?s", "/static/app.css", false},
+		{"/a+b/(c).js", "/a+b/(c).js", true},
+	} {
+		re, err := compilePurgePattern(tc.pattern)
+		assert.Nil(err)
+		assert.Equal(tc.match, re.MatchString(tc.file), "%s ~ %s", tc.pattern, tc.file)
+	}
+
+	_, err := compilePurgePattern("")
+	assert.NotNil(err)
+}
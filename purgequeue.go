@@ -0,0 +1,246 @@
+package maxcdn
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultPurgeWindow is the debounce window used by PurgeQueue when
+// PurgeQueueOptions.Window is not set.
+var DefaultPurgeWindow = 5 * time.Second
+
+// DefaultPurgeMaxWait bounds how long a purge is queued when
+// PurgeQueueOptions.MaxWait is not set.
+var DefaultPurgeMaxWait = 30 * time.Second
+
+// ErrPurgeQueueClosed is returned when adding to a closed PurgeQueue.
+var ErrPurgeQueueClosed = errors.New("maxcdn: purge queue closed")
+
+// PurgeQueueOptions configures a PurgeQueue.
+type PurgeQueueOptions struct {
+
+	// Window is the debounce window. The queue flushes once nothing has
+	// been added to it for Window.
+	Window time.Duration
+
+	// MaxWait bounds the debouncing: the queue flushes at most MaxWait
+	// after its oldest pending purge was added, even while purges keep
+	// arriving within Window. It's at least Window.
+	MaxWait time.Duration
+
+	// MaxSize flushes the queue as soon as this many purges are pending.
+	// Zero means no limit.
+	MaxSize int
+
+	// ZoneThreshold collapses a zone's file purges into a single zone
+	// purge when more than ZoneThreshold files are pending for it. Zero
+	// disables collapsing.
+	ZoneThreshold int
+
+	// OnFlush is called with the result of every zone flushed.
+	OnFlush func(PurgeQueueResult)
+}
+
+// PurgeQueueResult reports a flushed zone.
+type PurgeQueueResult struct {
+	Zone int
+
+	// Files holds the purged files, and is empty for zone purges.
+	Files     []string
+	ZonePurge bool
+	Responses []*Response
+	Err       error
+}
+
+// PurgeQueue is a long-lived queue which coalesces purges before sending
+// them through a MaxCDN client. Identical purges are sent once, file purges
+// are dropped when their zone is purged as a whole, and zones with more
+// files pending than the configured threshold are purged as a whole.
+//
+// A PurgeQueue is safe for concurrent use.
+type PurgeQueue struct {
+	max  *MaxCDN
+	opts PurgeQueueOptions
+
+	mu      sync.Mutex
+	pending map[int]*pendingPurge
+	size    int
+	timer   *time.Timer
+	closed  bool
+
+	// deadline is when the oldest pending purge has waited MaxWait.
+	deadline time.Time
+
+	// flushing serializes flushes, so a zone is never purged by two
+	// overlapping flushes.
+	flushing sync.Mutex
+}
+
+type pendingPurge struct {
+	zone  bool
+	files map[string]bool
+}
+
+// NewPurgeQueue sets up a new PurgeQueue sending purges through max.
+func NewPurgeQueue(max *MaxCDN, opts PurgeQueueOptions) *PurgeQueue {
+	if opts.Window <= 0 {
+		opts.Window = DefaultPurgeWindow
+	}
+	if opts.MaxWait <= 0 {
+		opts.MaxWait = DefaultPurgeMaxWait
+	}
+	if opts.MaxWait < opts.Window {
+		opts.MaxWait = opts.Window
+	}
+	return &PurgeQueue{
+		max:     max,
+		opts:    opts,
+		pending: map[int]*pendingPurge{},
+	}
+}
+
+// PurgeFile queues a file purge.
+func (q *PurgeQueue) PurgeFile(zone int, file string) error {
+	return q.PurgeFiles(zone, []string{file})
+}
+
+// PurgeFiles queues multiple file purges for a zone.
+func (q *PurgeQueue) PurgeFiles(zone int, files []string) error {
+	return q.add(zone, files, false)
+}
+
+// PurgeZone queues a zone purge.
+func (q *PurgeQueue) PurgeZone(zone int) error {
+	return q.add(zone, nil, true)
+}
+
+// Len returns the number of pending purges.
+func (q *PurgeQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.size
+}
+
+// Flush sends all pending purges and waits for them to complete.
+func (q *PurgeQueue) Flush() {
+	q.flushing.Lock()
+	defer q.flushing.Unlock()
+
+	q.mu.Lock()
+	pending := q.pending
+	q.pending = map[int]*pendingPurge{}
+	q.size = 0
+	if q.timer != nil {
+		q.timer.Stop()
+		q.timer = nil
+	}
+	q.mu.Unlock()
+
+	zones := make([]int, 0, len(pending))
+	for zone := range pending {
+		zones = append(zones, zone)
+	}
+	sort.Ints(zones)
+
+	for _, zone := range zones {
+		result := q.send(zone, pending[zone])
+		if q.opts.OnFlush != nil {
+			q.opts.OnFlush(result)
+		}
+	}
+}
+
+// Close flushes the queue and stops it from accepting new purges.
+func (q *PurgeQueue) Close() error {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return ErrPurgeQueueClosed
+	}
+	q.closed = true
+	q.mu.Unlock()
+
+	q.Flush()
+	return nil
+}
+
+func (q *PurgeQueue) add(zone int, files []string, whole bool) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return ErrPurgeQueueClosed
+	}
+
+	p, ok := q.pending[zone]
+	if !ok {
+		p = &pendingPurge{files: map[string]bool{}}
+		q.pending[zone] = p
+	}
+
+	switch {
+	case p.zone:
+		// Already purging the whole zone.
+	case whole:
+		q.size -= len(p.files) - 1
+		p.zone = true
+		p.files = map[string]bool{}
+	default:
+		for _, file := range files {
+			if !p.files[file] {
+				p.files[file] = true
+				q.size++
+			}
+		}
+	}
+
+	if q.opts.MaxSize > 0 && q.size >= q.opts.MaxSize {
+		if q.timer != nil {
+			q.timer.Stop()
+			q.timer = nil
+		}
+		go q.Flush()
+		return nil
+	}
+
+	now := time.Now()
+	if q.timer == nil {
+		q.deadline = now.Add(q.opts.MaxWait)
+	}
+	wait := q.opts.Window
+	if left := q.deadline.Sub(now); left < wait {
+		wait = left
+	}
+
+	if q.timer == nil {
+		q.timer = time.AfterFunc(wait, q.Flush)
+	} else {
+		q.timer.Reset(wait)
+	}
+	return nil
+}
+
+func (q *PurgeQueue) send(zone int, p *pendingPurge) PurgeQueueResult {
+	result := PurgeQueueResult{Zone: zone}
+
+	if p.zone || (q.opts.ZoneThreshold > 0 && len(p.files) > q.opts.ZoneThreshold) {
+		result.ZonePurge = true
+
+		rsp, err := q.max.PurgeZone(zone)
+		if rsp != nil {
+			result.Responses = []*Response{rsp}
+		}
+		result.Err = err
+		return result
+	}
+
+	for file := range p.files {
+		result.Files = append(result.Files, file)
+	}
+	sort.Strings(result.Files)
+
+	result.Responses, result.Err = q.max.PurgeFiles(zone, result.Files)
+	return result
+}
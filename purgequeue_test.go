@@ -0,0 +1,166 @@
+package maxcdn
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// purgeRecorder records the purges received by a stubbed client.
+type purgeRecorder struct {
+	mu       sync.Mutex
+	requests []string
+}
+
+func (rec *purgeRecorder) client() *http.Client {
+	return stubHTTPFunc(func(r *http.Request) (*http.Response, error) {
+		rec.mu.Lock()
+		rec.requests = append(rec.requests, r.URL.Path+"?"+r.URL.RawQuery)
+		rec.mu.Unlock()
+		return stubJSONResponse(r, 200, "delete.json"), nil
+	})
+}
+
+func (rec *purgeRecorder) all() []string {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return append([]string(nil), rec.requests...)
+}
+
+func TestPurgeQueue_dedupe(t *testing.T) {
+	assert := assert.New(t)
+	max := NewMaxCDN("alias", "token", "secret")
+
+	var rec purgeRecorder
+	max.HTTPClient = rec.client()
+
+	var results []PurgeQueueResult
+	q := NewPurgeQueue(max, PurgeQueueOptions{
+		Window:  time.Hour,
+		OnFlush: func(r PurgeQueueResult) { results = append(results, r) },
+	})
+
+	assert.Nil(q.PurgeFile(1, "/a.css"))
+	assert.Nil(q.PurgeFiles(1, []string{"/a.css", "/b.css"}))
+	assert.Nil(q.PurgeFile(2, "/a.css"))
+	assert.Equal(3, q.Len())
+
+	assert.Nil(q.Close())
+	assert.Equal(ErrPurgeQueueClosed, q.PurgeFile(1, "/c.css"))
+
+	assert.ElementsMatch([]string{
+		"/alias/zones/pull.json/1/cache?files=%2Fa.css",
+		"/alias/zones/pull.json/1/cache?files=%2Fb.css",
+		"/alias/zones/pull.json/2/cache?files=%2Fa.css",
+	}, rec.all())
+
+	assert.Len(results, 2)
+	assert.Equal(1, results[0].Zone)
+	assert.Equal([]string{"/a.css", "/b.css"}, results[0].Files)
+	assert.False(results[0].ZonePurge)
+	assert.Len(results[0].Responses, 2)
+	assert.Nil(results[0].Err)
+	assert.Equal(2, results[1].Zone)
+}
+
+func TestPurgeQueue_collapse(t *testing.T) {
+	assert := assert.New(t)
+	max := NewMaxCDN("alias", "token", "secret")
+
+	var rec purgeRecorder
+	max.HTTPClient = rec.client()
+
+	var results []PurgeQueueResult
+	q := NewPurgeQueue(max, PurgeQueueOptions{
+		Window:        time.Hour,
+		ZoneThreshold: 2,
+		OnFlush:       func(r PurgeQueueResult) { results = append(results, r) },
+	})
+
+	q.PurgeFiles(1, []string{"/a.css", "/b.css", "/c.css"})
+	q.PurgeFile(2, "/a.css")
+	q.PurgeZone(2)
+	q.PurgeFile(2, "/b.css")
+	assert.Equal(4, q.Len())
+	q.Flush()
+	assert.Equal(0, q.Len())
+
+	assert.Equal([]string{
+		"/alias/zones/pull.json/1/cache?",
+		"/alias/zones/pull.json/2/cache?",
+	}, rec.all())
+
+	assert.Len(results, 2)
+	assert.True(results[0].ZonePurge)
+	assert.True(results[1].ZonePurge)
+	assert.Empty(results[1].Files)
+}
+
+func TestPurgeQueue_triggers(t *testing.T) {
+	assert := assert.New(t)
+	max := NewMaxCDN("alias", "token", "secret")
+
+	var rec purgeRecorder
+	max.HTTPClient = rec.client()
+
+	flushed := make(chan PurgeQueueResult, 10)
+	onFlush := func(r PurgeQueueResult) { flushed <- r }
+
+	// size limit, with a window too long to flush instead
+	q := NewPurgeQueue(max, PurgeQueueOptions{Window: time.Hour, MaxSize: 2, OnFlush: onFlush})
+	q.PurgeFiles(1, []string{"/a.css", "/b.css"})
+	select {
+	case r := <-flushed:
+		assert.Equal([]string{"/a.css", "/b.css"}, r.Files)
+	case <-time.After(time.Second):
+		t.Fatal("queue did not flush on size limit")
+	}
+
+	// debounce window
+	q = NewPurgeQueue(max, PurgeQueueOptions{Window: 10 * time.Millisecond, OnFlush: onFlush})
+	q.PurgeFile(3, "/c.css")
+	select {
+	case r := <-flushed:
+		assert.Equal(3, r.Zone)
+	case <-time.After(time.Second):
+		t.Fatal("queue did not flush on debounce window")
+	}
+
+	assert.Len(rec.all(), 3)
+}
+
+func TestPurgeQueue_maxWait(t *testing.T) {
+	assert := assert.New(t)
+	max := NewMaxCDN("alias", "token", "secret")
+
+	var rec purgeRecorder
+	max.HTTPClient = rec.client()
+
+	flushed := make(chan PurgeQueueResult, 10)
+	q := NewPurgeQueue(max, PurgeQueueOptions{
+		Window:  50 * time.Millisecond,
+		MaxWait: 100 * time.Millisecond,
+		OnFlush: func(r PurgeQueueResult) { flushed <- r },
+	})
+	assert.Equal(100*time.Millisecond, q.opts.MaxWait)
+
+	// steady traffic within the window still flushes after MaxWait
+	stop := time.After(time.Second)
+	for i := 0; ; i++ {
+		q.PurgeFile(1, fmt.Sprintf("/%d.css", i))
+		select {
+		case r := <-flushed:
+			assert.Equal(1, r.Zone)
+			assert.NotEmpty(r.Files)
+			q.Close()
+			return
+		case <-stop:
+			t.Fatal("queue did not flush on max wait")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
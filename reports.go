@@ -0,0 +1,176 @@
+package maxcdn
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// StatusCodeStat holds the hits for a single HTTP status code. Timestamp is
+// only set when the report has an interval.
+type StatusCodeStat struct {
+	Timestamp  time.Time `json:"timestamp"`
+	StatusCode int       `json:"status_code"`
+	Hits       int64     `json:"hits"`
+}
+
+// FileTypeStat holds the traffic for a single file type, such as "css".
+// Timestamp is only set when the report has an interval.
+type FileTypeStat struct {
+	Timestamp time.Time `json:"timestamp"`
+	FileType  string    `json:"file_type"`
+	Hits      int64     `json:"hits"`
+	Bytes     int64     `json:"bytes"`
+}
+
+// FileSizeStat holds the hits for files up to Size bytes, bucketed by the
+// API. Timestamp is only set when the report has an interval.
+type FileSizeStat struct {
+	Timestamp time.Time `json:"timestamp"`
+	Size      int64     `json:"size"`
+	Hits      int64     `json:"hits"`
+}
+
+// NodeStat holds the traffic served by a single node, or POP, such as "atl".
+// Timestamp is only set when the report has an interval.
+type NodeStat struct {
+	Timestamp   time.Time `json:"timestamp"`
+	Node        string    `json:"node"`
+	Description string    `json:"description"`
+	StatsSummary
+}
+
+// StatusCodes fetches a status codes report.
+func (max *MaxCDN) StatusCodes(q StatsQuery) ([]StatusCodeStat, error) {
+	var stats []StatusCodeStat
+
+	err := max.eachReportItem(q, "statuscodes.json", "statuscodes", func(item json.RawMessage, ts time.Time) error {
+		var raw struct {
+			StatusCode jsonInt64 `json:"status_code"`
+			Hit        jsonInt64 `json:"hit"`
+		}
+		if err := json.Unmarshal(item, &raw); err != nil {
+			return err
+		}
+
+		stats = append(stats, StatusCodeStat{
+			Timestamp:  ts,
+			StatusCode: int(raw.StatusCode),
+			Hits:       int64(raw.Hit),
+		})
+		return nil
+	})
+	return stats, err
+}
+
+// FileTypes fetches a file types report.
+func (max *MaxCDN) FileTypes(q StatsQuery) ([]FileTypeStat, error) {
+	var stats []FileTypeStat
+
+	err := max.eachReportItem(q, "filetypes.json", "filetypes", func(item json.RawMessage, ts time.Time) error {
+		var raw struct {
+			FileType string    `json:"file_type"`
+			Hit      jsonInt64 `json:"hit"`
+			Size     jsonInt64 `json:"size"`
+		}
+		if err := json.Unmarshal(item, &raw); err != nil {
+			return err
+		}
+
+		stats = append(stats, FileTypeStat{
+			Timestamp: ts,
+			FileType:  raw.FileType,
+			Hits:      int64(raw.Hit),
+			Bytes:     int64(raw.Size),
+		})
+		return nil
+	})
+	return stats, err
+}
+
+// FileSizes fetches a file sizes report.
+func (max *MaxCDN) FileSizes(q StatsQuery) ([]FileSizeStat, error) {
+	var stats []FileSizeStat
+
+	err := max.eachReportItem(q, "filesizes.json", "filesizes", func(item json.RawMessage, ts time.Time) error {
+		var raw struct {
+			FileSize jsonInt64 `json:"file_size"`
+			Hit      jsonInt64 `json:"hit"`
+		}
+		if err := json.Unmarshal(item, &raw); err != nil {
+			return err
+		}
+
+		stats = append(stats, FileSizeStat{
+			Timestamp: ts,
+			Size:      int64(raw.FileSize),
+			Hits:      int64(raw.Hit),
+		})
+		return nil
+	})
+	return stats, err
+}
+
+// Nodes fetches a nodes report.
+func (max *MaxCDN) Nodes(q StatsQuery) ([]NodeStat, error) {
+	var stats []NodeStat
+
+	err := max.eachReportItem(q, "nodes.json", "nodes", func(item json.RawMessage, ts time.Time) error {
+		var raw struct {
+			rawStatsSummary
+			Name        string `json:"name"`
+			Description string `json:"description"`
+		}
+		if err := json.Unmarshal(item, &raw); err != nil {
+			return err
+		}
+
+		stats = append(stats, NodeStat{
+			Timestamp:    ts,
+			Node:         raw.Name,
+			Description:  raw.Description,
+			StatsSummary: raw.rawStatsSummary.typed(),
+		})
+		return nil
+	})
+	return stats, err
+}
+
+// eachReportItem calls fn with every item listed under key in a report,
+// along with the item's parsed timestamp when it has one.
+func (max *MaxCDN) eachReportItem(q StatsQuery, report, key string, fn func(json.RawMessage, time.Time) error) error {
+	return max.eachReportPage(q, report, func(data json.RawMessage, page reportPage) error {
+		var raw map[string]json.RawMessage
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return err
+		}
+
+		var items []json.RawMessage
+		if len(raw[key]) > 0 {
+			if err := json.Unmarshal(raw[key], &items); err != nil {
+				return err
+			}
+		}
+
+		for _, item := range items {
+			var stamped struct {
+				Timestamp string `json:"timestamp"`
+			}
+			if err := json.Unmarshal(item, &stamped); err != nil {
+				return err
+			}
+
+			var ts time.Time
+			if stamped.Timestamp != "" {
+				var err error
+				if ts, err = parseReportTime(stamped.Timestamp, q.location()); err != nil {
+					return err
+				}
+			}
+
+			if err := fn(item, ts); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,100 @@
+package maxcdn
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaxCDN_StatusCodes(t *testing.T) {
+	assert := assert.New(t)
+	max := NewMaxCDN("alias", "token", "secret")
+
+	var recorder http.Response
+	max.HTTPClient = stubHTTPOkRecorded(&recorder)
+
+	stats, err := max.StatusCodes(StatsQuery{Zone: 123456})
+	assert.Nil(err)
+	assert.Equal([]StatusCodeStat{
+		{StatusCode: 200, Hits: 16102},
+		{StatusCode: 304, Hits: 2311},
+		{StatusCode: 404, Hits: 219},
+	}, stats)
+	assert.Equal("/alias/reports/123456/statuscodes.json", recorder.Request.URL.Path)
+}
+
+func TestMaxCDN_StatusCodes_interval(t *testing.T) {
+	assert := assert.New(t)
+	max := NewMaxCDN("alias", "token", "secret")
+
+	max.HTTPClient = stubHTTPFunc(func(r *http.Request) (*http.Response, error) {
+		assert.Equal("/alias/reports/statuscodes.json/daily", r.URL.Path)
+		assert.Equal("2014-05-18", r.URL.Query().Get("date_from"))
+		return stubBodyResponse(r, 200, `{"code":200,"data":{"statuscodes":[
+			{"status_code":"200","hit":"10","timestamp":"2014-05-18"},
+			{"status_code":"200","hit":"12","timestamp":"2014-05-19"}]}}`), nil
+	})
+
+	stats, err := max.StatusCodes(StatsQuery{
+		Interval: StatsDaily,
+		From:     time.Date(2014, 5, 18, 0, 0, 0, 0, time.UTC),
+	})
+	assert.Nil(err)
+	assert.Len(stats, 2)
+	assert.Equal(time.Date(2014, 5, 19, 0, 0, 0, 0, time.UTC), stats[1].Timestamp)
+	assert.Equal(int64(12), stats[1].Hits)
+}
+
+func TestMaxCDN_FileTypes(t *testing.T) {
+	assert := assert.New(t)
+	max := NewMaxCDN("alias", "token", "secret")
+
+	var recorder http.Response
+	max.HTTPClient = stubHTTPOkRecorded(&recorder)
+
+	stats, err := max.FileTypes(StatsQuery{})
+	assert.Nil(err)
+	assert.Len(stats, 3)
+	assert.Equal(FileTypeStat{FileType: "css", Hits: 8230, Bytes: 9204112}, stats[0])
+	assert.Equal("/alias/reports/filetypes.json", recorder.Request.URL.Path)
+}
+
+func TestMaxCDN_FileSizes(t *testing.T) {
+	assert := assert.New(t)
+	max := NewMaxCDN("alias", "token", "secret")
+	max.HTTPClient = stubHTTPOk()
+
+	stats, err := max.FileSizes(StatsQuery{})
+	assert.Nil(err)
+	assert.Len(stats, 3)
+	assert.Equal(FileSizeStat{Size: 1048576, Hits: 1121}, stats[2])
+}
+
+func TestMaxCDN_Nodes(t *testing.T) {
+	assert := assert.New(t)
+	max := NewMaxCDN("alias", "token", "secret")
+	max.HTTPClient = stubHTTPOk()
+
+	stats, err := max.Nodes(StatsQuery{})
+	assert.Nil(err)
+	assert.Len(stats, 2)
+	assert.Equal(NodeStat{
+		Node:         "atl",
+		Description:  "Atlanta, GA",
+		StatsSummary: StatsSummary{Hits: 11482, CacheHits: 7310, NonCacheHits: 4172, Bytes: 12601032},
+	}, stats[0])
+}
+
+func TestMaxCDN_Nodes_error(t *testing.T) {
+	assert := assert.New(t)
+	max := NewMaxCDN("alias", "token", "secret")
+	max.HTTPClient = stubHTTPFunc(func(r *http.Request) (*http.Response, error) {
+		return stubBodyResponse(r, 400, stubAPIError), nil
+	})
+
+	stats, err := max.Nodes(StatsQuery{})
+	assert.NotNil(err)
+	assert.Empty(stats)
+}
@@ -0,0 +1,284 @@
+package maxcdn
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// StatsInterval is the granularity of a stats report.
+type StatsInterval string
+
+// Stats report intervals. An empty interval requests a summary only.
+const (
+	StatsHourly  StatsInterval = "hourly"
+	StatsDaily   StatsInterval = "daily"
+	StatsMonthly StatsInterval = "monthly"
+)
+
+// StatsQuery selects a report. It's shared by all report methods.
+type StatsQuery struct {
+
+	// Zone limits the report to a single zone. Zero reports on the whole
+	// account.
+	Zone int
+
+	Interval StatsInterval
+
+	// From and To limit the report to a date range. Either may be zero.
+	From time.Time
+	To   time.Time
+
+	// Location is the time zone of the account, which the API's days follow.
+	// From and To are encoded as dates in it, and report timestamps are
+	// parsed in it. When nil, From and To are encoded in their own location
+	// and timestamps are parsed as UTC. See WithRange.
+	Location *time.Location
+
+	// Page selects a single page of results. Zero fetches every page.
+	Page     int
+	PageSize int
+}
+
+// Values encodes the query parameters of q.
+func (q StatsQuery) Values() url.Values {
+	form := url.Values{}
+	if !q.From.IsZero() {
+		form.Set("date_from", q.date(q.From))
+	}
+	if !q.To.IsZero() {
+		form.Set("date_to", q.date(q.To))
+	}
+	if q.Page > 0 {
+		form.Set("page", strconv.Itoa(q.Page))
+	}
+	if q.PageSize > 0 {
+		form.Set("page_size", strconv.Itoa(q.PageSize))
+	}
+	return form
+}
+
+func (q StatsQuery) date(t time.Time) string {
+	if q.Location != nil {
+		t = t.In(q.Location)
+	}
+	return t.Format("2006-01-02")
+}
+
+func (q StatsQuery) location() *time.Location {
+	if q.Location == nil {
+		return time.UTC
+	}
+	return q.Location
+}
+
+// endpoint returns the path of a report, such as "stats.json", for q.
+func (q StatsQuery) endpoint(report string) string {
+	endpoint := "/reports/" + report
+	if q.Zone != 0 {
+		endpoint = fmt.Sprintf("/reports/%d/%s", q.Zone, report)
+	}
+	if q.Interval != "" {
+		endpoint += "/" + string(q.Interval)
+	}
+	return endpoint
+}
+
+// StatsSummary holds the traffic counters of a stats report.
+type StatsSummary struct {
+	Hits         int64 `json:"hits"`
+	CacheHits    int64 `json:"cache_hits"`
+	NonCacheHits int64 `json:"noncache_hits"`
+	Bytes        int64 `json:"bytes"`
+}
+
+// Add returns the sum of s and o.
+func (s StatsSummary) Add(o StatsSummary) StatsSummary {
+	return StatsSummary{
+		Hits:         s.Hits + o.Hits,
+		CacheHits:    s.CacheHits + o.CacheHits,
+		NonCacheHits: s.NonCacheHits + o.NonCacheHits,
+		Bytes:        s.Bytes + o.Bytes,
+	}
+}
+
+// StatsPoint holds the traffic counters of a single interval, starting at
+// Timestamp.
+type StatsPoint struct {
+	Timestamp time.Time `json:"timestamp"`
+	StatsSummary
+}
+
+// StatsSeries is a stats report broken down by interval, in the order
+// returned by the API.
+type StatsSeries []StatsPoint
+
+// Summary returns the sum of every point in the series.
+func (series StatsSeries) Summary() StatsSummary {
+	var sum StatsSummary
+	for _, p := range series {
+		sum = sum.Add(p.StatsSummary)
+	}
+	return sum
+}
+
+// Stats is a typed stats report.
+type Stats struct {
+	Interval StatsInterval
+	Summary  StatsSummary
+
+	// Series is empty when no interval was requested.
+	Series StatsSeries
+
+	Page  int
+	Pages int
+	Total int
+}
+
+// GetStats fetches a stats report.
+func (max *MaxCDN) GetStats(q StatsQuery) (*Stats, error) {
+	stats := &Stats{Interval: q.Interval}
+
+	err := max.eachReportPage(q, "stats.json", func(data json.RawMessage, page reportPage) error {
+		var raw struct {
+			Stats   json.RawMessage  `json:"stats"`
+			Summary *rawStatsSummary `json:"summary"`
+		}
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return err
+		}
+
+		if q.Interval == "" {
+			var summary rawStatsSummary
+			if err := json.Unmarshal(raw.Stats, &summary); err != nil {
+				return err
+			}
+			stats.Summary = summary.typed()
+		} else {
+			var points []rawStatsPoint
+			if err := json.Unmarshal(raw.Stats, &points); err != nil {
+				return err
+			}
+			for _, p := range points {
+				point, err := p.typed(q.location())
+				if err != nil {
+					return err
+				}
+				stats.Series = append(stats.Series, point)
+			}
+			if raw.Summary != nil {
+				stats.Summary = raw.Summary.typed()
+			}
+		}
+
+		stats.Page, stats.Pages, stats.Total = page.Page, page.Pages, int(page.Total)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if q.Interval != "" && stats.Summary == (StatsSummary{}) {
+		stats.Summary = stats.Series.Summary()
+	}
+	return stats, nil
+}
+
+// reportPage holds the paging fields common to all reports.
+type reportPage struct {
+	Page     int       `json:"page"`
+	Pages    int       `json:"pages"`
+	PageSize jsonInt64 `json:"page_size"`
+	Total    jsonInt64 `json:"total"`
+}
+
+// errStopPaging may be returned by an eachReportPage callback to skip the
+// remaining pages.
+var errStopPaging = errors.New("maxcdn: stop paging")
+
+// eachReportPage fetches a report and calls fn with the data of every page,
+// or only the page selected by q.Page.
+func (max *MaxCDN) eachReportPage(q StatsQuery, report string, fn func(json.RawMessage, reportPage) error) error {
+	endpoint := q.endpoint(report)
+
+	for page := q.Page; ; page++ {
+		form := q.Values()
+		if page > 0 {
+			form.Set("page", strconv.Itoa(page))
+		}
+
+		rsp, err := max.Do("GET", endpoint, form)
+		if err != nil {
+			return err
+		}
+
+		var paging reportPage
+		if err := json.Unmarshal(rsp.Data, &paging); err != nil {
+			return err
+		}
+		if err := fn(rsp.Data, paging); err == errStopPaging {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if q.Page > 0 || paging.Page >= paging.Pages || paging.Page < page {
+			return nil
+		}
+		if page == 0 {
+			page = paging.Page
+		}
+	}
+}
+
+type rawStatsSummary struct {
+	CacheHit    jsonInt64 `json:"cache_hit"`
+	Hit         jsonInt64 `json:"hit"`
+	NonCacheHit jsonInt64 `json:"noncache_hit"`
+	Size        jsonInt64 `json:"size"`
+}
+
+func (raw rawStatsSummary) typed() StatsSummary {
+	return StatsSummary{
+		Hits:         int64(raw.Hit),
+		CacheHits:    int64(raw.CacheHit),
+		NonCacheHits: int64(raw.NonCacheHit),
+		Bytes:        int64(raw.Size),
+	}
+}
+
+type rawStatsPoint struct {
+	rawStatsSummary
+	Timestamp string `json:"timestamp"`
+}
+
+func (raw rawStatsPoint) typed(loc *time.Location) (StatsPoint, error) {
+	ts, err := parseReportTime(raw.Timestamp, loc)
+	if err != nil {
+		return StatsPoint{}, err
+	}
+	return StatsPoint{Timestamp: ts, StatsSummary: raw.rawStatsSummary.typed()}, nil
+}
+
+// reportTimeLayouts are the timestamp formats used by the reports.
+var reportTimeLayouts = []string{
+	"2006-01-02",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01",
+	time.RFC3339,
+}
+
+// parseReportTime parses a report timestamp in loc. Timestamps with a zone
+// offset are converted to loc.
+func parseReportTime(s string, loc *time.Location) (time.Time, error) {
+	for _, layout := range reportTimeLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t.In(loc), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("maxcdn: unknown report timestamp %q", s)
+}
@@ -0,0 +1,122 @@
+package maxcdn
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatsQuery_Values(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("", StatsQuery{}.Values().Encode())
+
+	q := StatsQuery{
+		From:     time.Date(2014, 5, 18, 0, 0, 0, 0, time.UTC),
+		To:       time.Date(2014, 6, 18, 0, 0, 0, 0, time.UTC),
+		Page:     2,
+		PageSize: 100,
+	}
+	assert.Equal("date_from=2014-05-18&date_to=2014-06-18&page=2&page_size=100", q.Values().Encode())
+}
+
+func TestStatsQuery_endpoint(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("/reports/stats.json", StatsQuery{}.endpoint("stats.json"))
+	assert.Equal("/reports/stats.json/hourly", StatsQuery{Interval: StatsHourly}.endpoint("stats.json"))
+	assert.Equal("/reports/123/stats.json/daily", StatsQuery{Zone: 123, Interval: StatsDaily}.endpoint("stats.json"))
+}
+
+func TestMaxCDN_GetStats_summary(t *testing.T) {
+	assert := assert.New(t)
+	max := NewMaxCDN("alias", "token", "secret")
+
+	var recorder http.Response
+	max.HTTPClient = stubHTTPOkRecorded(&recorder)
+
+	stats, err := max.GetStats(StatsQuery{})
+	assert.Nil(err)
+	assert.Equal(StatsSummary{Hits: 18632, CacheHits: 11571, NonCacheHits: 7061, Bytes: 20402029}, stats.Summary)
+	assert.Empty(stats.Series)
+	assert.Equal(1, stats.Total)
+
+	assert.Equal("GET", recorder.Request.Method)
+	assert.Equal("/alias/reports/stats.json", recorder.Request.URL.Path)
+}
+
+func TestMaxCDN_GetStats_daily(t *testing.T) {
+	assert := assert.New(t)
+	max := NewMaxCDN("alias", "token", "secret")
+
+	var recorder http.Response
+	max.HTTPClient = stubHTTPOkRecorded(&recorder)
+
+	stats, err := max.GetStats(StatsQuery{
+		Zone:     164197,
+		Interval: StatsDaily,
+		From:     time.Date(2014, 5, 18, 0, 0, 0, 0, time.UTC),
+	})
+	assert.Nil(err)
+	assert.Equal(StatsDaily, stats.Interval)
+	assert.Equal(StatsSummary{Hits: 18730, CacheHits: 11633, NonCacheHits: 7097, Bytes: 20538793}, stats.Summary)
+	assert.Equal(stats.Summary, stats.Series.Summary())
+	assert.Len(stats.Series, 32)
+	assert.Equal(1, stats.Page)
+	assert.Equal(1, stats.Pages)
+	assert.Equal(32, stats.Total)
+
+	first := stats.Series[0]
+	assert.Equal(time.Date(2014, 5, 18, 0, 0, 0, 0, time.UTC), first.Timestamp)
+	assert.Equal(StatsSummary{Hits: 267, CacheHits: 122, NonCacheHits: 145, Bytes: 235724}, first.StatsSummary)
+
+	assert.Equal("/alias/reports/164197/stats.json/daily", recorder.Request.URL.Path)
+	assert.Equal("2014-05-18", recorder.Request.URL.Query().Get("date_from"))
+}
+
+func TestMaxCDN_GetStats_pages(t *testing.T) {
+	assert := assert.New(t)
+	max := NewMaxCDN("alias", "token", "secret")
+
+	var pages []string
+	max.HTTPClient = stubHTTPFunc(func(r *http.Request) (*http.Response, error) {
+		page := r.URL.Query().Get("page")
+		pages = append(pages, page)
+		if page == "" {
+			page = "1"
+		}
+		return stubBodyResponse(r, 200, `{"code":200,"data":{"page":`+page+`,"pages":3,"total":"3",
+			"stats":[{"hit":"1","cache_hit":null,"noncache_hit":1,"size":"10","timestamp":"2014-05-18 0`+page+`:00:00"}]}}`), nil
+	})
+
+	stats, err := max.GetStats(StatsQuery{Interval: StatsHourly})
+	assert.Nil(err)
+	assert.Equal([]string{"", "2", "3"}, pages)
+	assert.Len(stats.Series, 3)
+	assert.Equal(StatsSummary{Hits: 3, NonCacheHits: 3, Bytes: 30}, stats.Summary)
+	assert.Equal(time.Date(2014, 5, 18, 3, 0, 0, 0, time.UTC), stats.Series[2].Timestamp)
+
+	pages = nil
+	stats, err = max.GetStats(StatsQuery{Interval: StatsHourly, Page: 2})
+	assert.Nil(err)
+	assert.Equal([]string{"2"}, pages)
+	assert.Len(stats.Series, 1)
+}
+
+func TestJSONInt64(t *testing.T) {
+	assert := assert.New(t)
+
+	var v struct{ A, B, C, D, E jsonInt64 }
+	err := json.Unmarshal([]byte(`{"A":"11571","B":42,"C":null,"D":"","E":1.5e3}`), &v)
+	assert.Nil(err)
+	assert.Equal(jsonInt64(11571), v.A)
+	assert.Equal(jsonInt64(42), v.B)
+	assert.Equal(jsonInt64(0), v.C)
+	assert.Equal(jsonInt64(0), v.D)
+	assert.Equal(jsonInt64(1500), v.E)
+
+	assert.NotNil(json.Unmarshal([]byte(`{"A":"abc"}`), &v))
+}
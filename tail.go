@@ -0,0 +1,169 @@
+package maxcdn
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LogsIngestionDelay is how long records take to show up in the logs API.
+// TailLogs only queries windows ending this long ago, and queries every
+// window again for records arriving up to twice as late.
+var LogsIngestionDelay = time.Minute
+
+// DefaultTailInterval is used by TailLogs for intervals of zero.
+const DefaultTailInterval = 10 * time.Second
+
+// TailLogs polls the logs API every interval for records matching q, like
+// tail -f. The Start, End and PageKey of q are ignored.
+//
+// New records are sent in time order within every poll, and records seen
+// in overlapping windows are only sent once. Polling errors are sent on the
+// error channel when it's ready, and the window is retried on the next
+// poll. Both channels are closed once ctx is done.
+func (max *MaxCDN) TailLogs(ctx context.Context, q LogsQuery, interval time.Duration) (<-chan LogRecord, <-chan error) {
+	if interval <= 0 {
+		interval = DefaultTailInterval
+	}
+
+	var (
+		records = make(chan LogRecord)
+		errs    = make(chan error, 1)
+		delay   = LogsIngestionDelay
+		tail    = newLogTail(max, q, delay, time.Now().Add(-delay-interval))
+	)
+
+	go func() {
+		defer close(errs)
+		defer close(records)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			fresh, err := tail.poll(time.Now().Add(-delay))
+			if err != nil {
+				select {
+				case errs <- err:
+				default:
+				}
+			}
+
+			for _, r := range fresh {
+				select {
+				case records <- r:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return records, errs
+}
+
+// logTail tracks the windows queried by TailLogs.
+type logTail struct {
+	max     *MaxCDN
+	q       LogsQuery
+	overlap time.Duration
+
+	// cursor is the end of the last window queried.
+	cursor time.Time
+
+	// sent counts the records sent by key, which repeat for identical
+	// requests, with the time of the record.
+	sent map[string]tailSent
+}
+
+type tailSent struct {
+	count int
+	time  time.Time
+}
+
+func newLogTail(max *MaxCDN, q LogsQuery, overlap time.Duration, cursor time.Time) *logTail {
+	q.PageKey = ""
+	return &logTail{max: max, q: q, overlap: overlap, cursor: cursor, sent: map[string]tailSent{}}
+}
+
+// poll queries the window from the cursor, less the overlap, to end and
+// returns the records not sent yet, sorted by time.
+func (t *logTail) poll(end time.Time) ([]LogRecord, error) {
+	// The API takes whole seconds.
+	end = end.Truncate(time.Second)
+	start := t.cursor.Add(-t.overlap).Truncate(time.Second)
+	if end.Sub(start) > MaxLogsWindow {
+		start = end.Add(-MaxLogsWindow)
+	}
+	if !end.After(start) {
+		return nil, nil
+	}
+
+	q := t.q
+	q.Start, q.End = start, end
+
+	var (
+		it     = t.max.IterateLogs(q)
+		counts = map[string]int{}
+		fresh  []LogRecord
+	)
+	for it.Next() {
+		r := it.Record()
+		ts := r.Timestamp()
+		if ts.Before(start) || !ts.Before(end) {
+			continue
+		}
+
+		key := logRecordKey(r)
+		counts[key]++
+		if counts[key] > t.sent[key].count {
+			fresh = append(fresh, r)
+		}
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, r := range fresh {
+		key := logRecordKey(r)
+		t.sent[key] = tailSent{count: counts[key], time: r.Timestamp()}
+	}
+
+	// Forget records which the next window won't overlap.
+	for key, s := range t.sent {
+		if s.time.Before(end.Add(-t.overlap)) {
+			delete(t.sent, key)
+		}
+	}
+	t.cursor = end
+
+	sort.SliceStable(fresh, func(i, j int) bool {
+		return fresh[i].Timestamp().Before(fresh[j].Timestamp())
+	})
+	return fresh, nil
+}
+
+// logRecordKey identifies a request, as records have no ID.
+func logRecordKey(r LogRecord) string {
+	return strings.Join([]string{
+		r.Time,
+		r.ClientIP,
+		r.Method,
+		r.Hostname,
+		r.URI,
+		r.QueryString,
+		strconv.Itoa(r.Status),
+		strconv.Itoa(r.Bytes),
+		r.Pop,
+		r.UserAgent,
+		strconv.Itoa(r.ZoneID),
+	}, "\x00")
+}
@@ -0,0 +1,140 @@
+package maxcdn
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// stubLogsAPI serves the ingested records within the start and end of the
+// query, newest first.
+type stubLogsAPI struct {
+	mu       sync.Mutex
+	ingested []LogRecord
+	windows  [][2]string
+	fail     bool
+}
+
+func (s *stubLogsAPI) ingest(uri string, t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ingested = append([]LogRecord{{URI: uri, Time: t.UTC().Format(time.RFC3339Nano)}}, s.ingested...)
+}
+
+func (s *stubLogsAPI) client() *http.Client {
+	return stubHTTPFunc(func(r *http.Request) (*http.Response, error) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		query := r.URL.Query()
+		s.windows = append(s.windows, [2]string{query.Get("start"), query.Get("end")})
+		if s.fail {
+			return stubBodyResponse(r, 502, "Bad Gateway"), nil
+		}
+
+		start, _ := time.Parse(logsTimeLayout, query.Get("start"))
+		end, _ := time.Parse(logsTimeLayout, query.Get("end"))
+
+		logs := Logs{Limit: 100}
+		for _, rec := range s.ingested {
+			ts := rec.Timestamp()
+			if !ts.Before(start) && !ts.After(end) {
+				logs.Records = append(logs.Records, rec)
+			}
+		}
+		body, _ := json.Marshal(logs)
+		return stubBodyResponse(r, 200, string(body)), nil
+	})
+}
+
+func uris(records []LogRecord) []string {
+	var s []string
+	for _, r := range records {
+		s = append(s, r.URI)
+	}
+	return s
+}
+
+func TestLogTail_poll(t *testing.T) {
+	assert := assert.New(t)
+	max := NewMaxCDN("alias", "token", "secret")
+
+	api := &stubLogsAPI{}
+	max.HTTPClient = api.client()
+
+	t0 := time.Date(2014, 7, 1, 16, 0, 0, 0, time.UTC)
+	tail := newLogTail(max, LogsQuery{Zones: []int{1}, PageKey: "ignored"}, time.Minute, t0)
+
+	api.ingest("/a", t0.Add(10*time.Second))
+	api.ingest("/b", t0.Add(5*time.Second))
+	api.ingest("/b", t0.Add(5*time.Second))
+
+	records, err := tail.poll(t0.Add(30 * time.Second))
+	assert.Nil(err)
+	assert.Equal([]string{"/b", "/b", "/a"}, uris(records))
+	assert.Equal([2]string{"2014-07-01T15:59:00Z", "2014-07-01T16:00:30Z"}, api.windows[0])
+
+	// a late record within the overlap, and a third identical request
+	api.ingest("/late", t0.Add(20*time.Second))
+	api.ingest("/b", t0.Add(5*time.Second))
+	api.ingest("/c", t0.Add(40*time.Second))
+
+	records, err = tail.poll(t0.Add(60 * time.Second))
+	assert.Nil(err)
+	assert.Equal([]string{"/b", "/late", "/c"}, uris(records))
+
+	// errors leave the cursor alone
+	api.fail = true
+	_, err = tail.poll(t0.Add(90 * time.Second))
+	assert.NotNil(err)
+
+	api.fail = false
+	records, err = tail.poll(t0.Add(90 * time.Second))
+	assert.Nil(err)
+	assert.Empty(records)
+	assert.Equal([2]string{"2014-07-01T16:00:00Z", "2014-07-01T16:01:30Z"}, api.windows[len(api.windows)-1])
+
+	// records older than the overlap are forgotten
+	for _, s := range tail.sent {
+		assert.False(s.time.Before(t0.Add(30 * time.Second)))
+	}
+}
+
+func TestMaxCDN_TailLogs(t *testing.T) {
+	assert := assert.New(t)
+	max := NewMaxCDN("alias", "token", "secret")
+
+	api := &stubLogsAPI{}
+	max.HTTPClient = api.client()
+
+	delay := LogsIngestionDelay
+	LogsIngestionDelay = time.Second
+	defer func() { LogsIngestionDelay = delay }()
+
+	api.ingest("/old", time.Now().Add(-time.Hour))
+	api.ingest("/a", time.Now().Add(-1500*time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	records, errs := max.TailLogs(ctx, LogsQuery{}, 10*time.Millisecond)
+
+	select {
+	case r := <-records:
+		assert.Equal("/a", r.URI)
+	case err := <-errs:
+		t.Fatal(err)
+	case <-time.After(3 * time.Second):
+		t.Fatal("no record")
+	}
+
+	cancel()
+	for range records {
+	}
+	_, ok := <-errs
+	assert.False(ok)
+}
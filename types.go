@@ -3,6 +3,8 @@ package maxcdn
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
+	"strings"
 )
 
 // Error represent a maxcnd error.
@@ -16,6 +18,18 @@ func (e Error) Error() string {
 	return e.Type + ": " + e.Message
 }
 
+// APIError is returned for responses with an error code.
+type APIError struct {
+	Code    int
+	Type    string
+	Message string
+}
+
+// Error implements go's error interface.
+func (e *APIError) Error() string {
+	return e.Type + ": " + e.Message
+}
+
 // Response object for all json requests.
 type Response struct {
 	Code  int             `json:"code,omitempty"`
@@ -66,3 +80,26 @@ type Logs struct {
 	Records     []LogRecord `json:"records"`
 	RequestTime int         `json:"request_time"`
 }
+
+// jsonInt64 decodes API counters, which are sent as numbers, numeric
+// strings or null.
+type jsonInt64 int64
+
+func (i *jsonInt64) UnmarshalJSON(b []byte) error {
+	s := strings.Trim(string(b), `"`)
+	if s == "" || s == "null" {
+		*i = 0
+		return nil
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		f, ferr := strconv.ParseFloat(s, 64)
+		if ferr != nil {
+			return err
+		}
+		n = int64(f)
+	}
+	*i = jsonInt64(n)
+	return nil
+}
@@ -0,0 +1,240 @@
+package maxcdn
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sync"
+)
+
+// AgentKind is the class of client behind a user agent.
+type AgentKind string
+
+// Agent kinds. Every kind but AgentBrowser and AgentUnknown is automated
+// traffic.
+const (
+	AgentBrowser  AgentKind = "browser"
+	AgentCrawler  AgentKind = "crawler"
+	AgentHeadless AgentKind = "headless"
+	AgentLibrary  AgentKind = "library"
+	AgentBot      AgentKind = "bot"
+	AgentUnknown  AgentKind = "unknown"
+)
+
+// DeviceClass is the form factor of a device.
+type DeviceClass string
+
+// Device classes.
+const (
+	DeviceDesktop DeviceClass = "desktop"
+	DeviceMobile  DeviceClass = "mobile"
+	DeviceTablet  DeviceClass = "tablet"
+	DeviceUnknown DeviceClass = "unknown"
+)
+
+// UserAgent is the classification of a user agent.
+type UserAgent struct {
+	Kind AgentKind `json:"kind"`
+
+	// Name is the name of the matching rule, such as "Googlebot" or
+	// "curl", and empty when no agent rule matched.
+	Name   string      `json:"name,omitempty"`
+	Device DeviceClass `json:"device"`
+}
+
+// IsAutomated reports whether the user agent is a crawler, bot, headless
+// browser or HTTP library.
+func (ua UserAgent) IsAutomated() bool {
+	return ua.Kind != AgentBrowser && ua.Kind != AgentUnknown
+}
+
+// UserAgentRule classifies user agents matching a case insensitive regular
+// expression.
+type UserAgentRule struct {
+	Name    string    `json:"name"`
+	Kind    AgentKind `json:"kind"`
+	Pattern string    `json:"pattern"`
+}
+
+// DeviceRule classifies the devices of user agents matching a case
+// insensitive regular expression, and not matching Exclude when set.
+type DeviceRule struct {
+	Device  DeviceClass `json:"device"`
+	Pattern string      `json:"pattern"`
+	Exclude string      `json:"exclude,omitempty"`
+}
+
+// UserAgentRules is a rule set. The first matching rule of each list wins.
+type UserAgentRules struct {
+	Agents  []UserAgentRule `json:"agents"`
+	Devices []DeviceRule    `json:"devices"`
+}
+
+// DefaultUserAgentRules is the built in rule set. Updated rules may be
+// loaded with LoadUserAgentRules.
+var DefaultUserAgentRules = UserAgentRules{
+	Agents: []UserAgentRule{
+		// Search engine and social crawlers.
+		{Name: "Googlebot", Kind: AgentCrawler, Pattern: `googlebot|adsbot-google|mediapartners-google|google-inspectiontool`},
+		{Name: "Bingbot", Kind: AgentCrawler, Pattern: `bingbot|bingpreview|msnbot`},
+		{Name: "Yahoo Slurp", Kind: AgentCrawler, Pattern: `yahoo! slurp`},
+		{Name: "DuckDuckBot", Kind: AgentCrawler, Pattern: `duckduckbot`},
+		{Name: "Baiduspider", Kind: AgentCrawler, Pattern: `baiduspider`},
+		{Name: "YandexBot", Kind: AgentCrawler, Pattern: `yandex(bot|images|mobilebot)`},
+		{Name: "Applebot", Kind: AgentCrawler, Pattern: `applebot`},
+		{Name: "facebookexternalhit", Kind: AgentCrawler, Pattern: `facebookexternalhit|facebot`},
+		{Name: "Twitterbot", Kind: AgentCrawler, Pattern: `twitterbot`},
+		{Name: "LinkedInBot", Kind: AgentCrawler, Pattern: `linkedinbot`},
+		{Name: "AhrefsBot", Kind: AgentCrawler, Pattern: `ahrefsbot`},
+		{Name: "SemrushBot", Kind: AgentCrawler, Pattern: `semrushbot`},
+		{Name: "MJ12bot", Kind: AgentCrawler, Pattern: `mj12bot`},
+
+		// Headless browsers, which otherwise look like browsers.
+		{Name: "HeadlessChrome", Kind: AgentHeadless, Pattern: `headlesschrome`},
+		{Name: "PhantomJS", Kind: AgentHeadless, Pattern: `phantomjs`},
+		{Name: "SlimerJS", Kind: AgentHeadless, Pattern: `slimerjs`},
+		{Name: "Puppeteer", Kind: AgentHeadless, Pattern: `puppeteer`},
+		{Name: "Selenium", Kind: AgentHeadless, Pattern: `selenium|webdriver`},
+
+		// HTTP libraries and command line tools.
+		{Name: "curl", Kind: AgentLibrary, Pattern: `^curl/`},
+		{Name: "Wget", Kind: AgentLibrary, Pattern: `^wget/`},
+		{Name: "Go", Kind: AgentLibrary, Pattern: `^go-http-client/|^go [0-9.]+ package http`},
+		{Name: "Python", Kind: AgentLibrary, Pattern: `python-requests/|python-urllib|aiohttp/|^python/`},
+		{Name: "Java", Kind: AgentLibrary, Pattern: `^java/|apache-httpclient/|okhttp/`},
+		{Name: "Ruby", Kind: AgentLibrary, Pattern: `^ruby|faraday v`},
+		{Name: "Node.js", Kind: AgentLibrary, Pattern: `^node-fetch/|^axios/|^got \(`},
+		{Name: "libwww-perl", Kind: AgentLibrary, Pattern: `libwww-perl/`},
+		{Name: "PHP", Kind: AgentLibrary, Pattern: `guzzlehttp/|^php/`},
+
+		// Anything else calling itself a bot.
+		{Name: "", Kind: AgentBot, Pattern: `bot\b|crawl|spider|slurp|scraper|monitor|pingdom|uptime`},
+
+		{Name: "", Kind: AgentBrowser, Pattern: `^mozilla/|^opera/`},
+	},
+	Devices: []DeviceRule{
+		{Device: DeviceTablet, Pattern: `ipad|tablet|kindle|silk/|playbook`},
+
+		// Android tablets leave out "Mobile".
+		{Device: DeviceTablet, Pattern: `android`, Exclude: `mobi`},
+		{Device: DeviceMobile, Pattern: `mobi|iphone|ipod|android|windows phone|blackberry|opera mini`},
+		{Device: DeviceDesktop, Pattern: `windows nt|macintosh|x11|cros|linux`},
+	},
+}
+
+// UserAgentClassifier classifies user agents with a rule set. It caches
+// recent results and is safe for concurrent use.
+type UserAgentClassifier struct {
+	agents  []compiledAgentRule
+	devices []compiledDeviceRule
+
+	mu    sync.Mutex
+	cache map[string]UserAgent
+}
+
+type compiledAgentRule struct {
+	UserAgentRule
+	re *regexp.Regexp
+}
+
+type compiledDeviceRule struct {
+	DeviceRule
+	re      *regexp.Regexp
+	exclude *regexp.Regexp
+}
+
+// userAgentCacheSize bounds the classifier cache, which is cleared when
+// full.
+const userAgentCacheSize = 10000
+
+// NewUserAgentClassifier compiles a rule set.
+func NewUserAgentClassifier(rules UserAgentRules) (*UserAgentClassifier, error) {
+	c := &UserAgentClassifier{cache: map[string]UserAgent{}}
+	for _, rule := range rules.Agents {
+		re, err := regexp.Compile("(?i)" + rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("maxcdn: invalid user agent rule %q: %v", rule.Name, err)
+		}
+		c.agents = append(c.agents, compiledAgentRule{UserAgentRule: rule, re: re})
+	}
+	for _, rule := range rules.Devices {
+		re, err := regexp.Compile("(?i)" + rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("maxcdn: invalid device rule %q: %v", rule.Device, err)
+		}
+		compiled := compiledDeviceRule{DeviceRule: rule, re: re}
+		if rule.Exclude != "" {
+			if compiled.exclude, err = regexp.Compile("(?i)" + rule.Exclude); err != nil {
+				return nil, fmt.Errorf("maxcdn: invalid device rule %q: %v", rule.Device, err)
+			}
+		}
+		c.devices = append(c.devices, compiled)
+	}
+	return c, nil
+}
+
+// LoadUserAgentRules reads a JSON rule set, in the format of
+// UserAgentRules, and compiles it.
+func LoadUserAgentRules(r io.Reader) (*UserAgentClassifier, error) {
+	var rules UserAgentRules
+	if err := json.NewDecoder(r).Decode(&rules); err != nil {
+		return nil, err
+	}
+	return NewUserAgentClassifier(rules)
+}
+
+// Classify classifies a user agent. Empty agents, and the "-" logged for
+// them, are of kind AgentUnknown.
+func (c *UserAgentClassifier) Classify(ua string) UserAgent {
+	c.mu.Lock()
+	result, ok := c.cache[ua]
+	c.mu.Unlock()
+	if ok {
+		return result
+	}
+
+	result = UserAgent{Kind: AgentUnknown, Device: DeviceUnknown}
+	if ua != "" && ua != "-" {
+		for _, rule := range c.agents {
+			if rule.re.MatchString(ua) {
+				result.Kind, result.Name = rule.Kind, rule.Name
+				break
+			}
+		}
+		for _, rule := range c.devices {
+			if rule.re.MatchString(ua) && (rule.exclude == nil || !rule.exclude.MatchString(ua)) {
+				result.Device = rule.Device
+				break
+			}
+		}
+	}
+
+	c.mu.Lock()
+	if len(c.cache) >= userAgentCacheSize {
+		c.cache = map[string]UserAgent{}
+	}
+	c.cache[ua] = result
+	c.mu.Unlock()
+	return result
+}
+
+// DefaultUserAgentClassifier uses DefaultUserAgentRules.
+var DefaultUserAgentClassifier *UserAgentClassifier
+
+func init() {
+	var err error
+	if DefaultUserAgentClassifier, err = NewUserAgentClassifier(DefaultUserAgentRules); err != nil {
+		panic(err)
+	}
+}
+
+// ClassifyUserAgent classifies a user agent with the default classifier.
+func ClassifyUserAgent(ua string) UserAgent {
+	return DefaultUserAgentClassifier.Classify(ua)
+}
+
+// Agent classifies the record's user agent with the default classifier.
+func (r LogRecord) Agent() UserAgent {
+	return ClassifyUserAgent(r.UserAgent)
+}
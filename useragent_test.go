@@ -0,0 +1,82 @@
+package maxcdn
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyUserAgent(t *testing.T) {
+	assert := assert.New(t)
+
+	for ua, want := range map[string]UserAgent{
+		"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_9_3) AppleWebKit/537.76.4 (KHTML, like Gecko) Version/7.0.4 Safari/537.76.4":                                         {AgentBrowser, "", DeviceDesktop},
+		"Mozilla/5.0 (Windows NT 6.1; WOW64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/35.0.1916.153 Safari/537.36":                                                 {AgentBrowser, "", DeviceDesktop},
+		"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Ubuntu Chromium/34.0.1847.116 Chrome/34.0.1847.116 Safari/537.36":                       {AgentBrowser, "", DeviceDesktop},
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 7_1 like Mac OS X) AppleWebKit/537.51.2 (KHTML, like Gecko) Version/7.0 Mobile/11D167 Safari/9537.53":                       {AgentBrowser, "", DeviceMobile},
+		"Mozilla/5.0 (iPad; CPU OS 7_1 like Mac OS X) AppleWebKit/537.51.2 (KHTML, like Gecko) Version/7.0 Mobile/11D167 Safari/9537.53":                                {AgentBrowser, "", DeviceTablet},
+		"Mozilla/5.0 (Linux; Android 4.4.2; Nexus 5 Build/KOT49H) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/35.0.1916.141 Mobile Safari/537.36":                     {AgentBrowser, "", DeviceMobile},
+		"Mozilla/5.0 (Linux; Android 4.4.2; Nexus 7 Build/KOT49H) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/35.0.1916.141 Safari/537.36":                            {AgentBrowser, "", DeviceTablet},
+		"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)":                                                                                      {AgentCrawler, "Googlebot", DeviceUnknown},
+		"Mozilla/5.0 (Linux; Android 6.0.1; Nexus 5X Build/MMB29P) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/41.0 Mobile Safari/537.36 (compatible; Googlebot/2.1)": {AgentCrawler, "Googlebot", DeviceMobile},
+		"Mozilla/5.0 (compatible; bingbot/2.0; +http://www.bing.com/bingbot.htm)":                                                                                       {AgentCrawler, "Bingbot", DeviceUnknown},
+		"facebookexternalhit/1.1 (+http://www.facebook.com/externalhit_uatext.php)":                                                                                     {AgentCrawler, "facebookexternalhit", DeviceUnknown},
+		"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) HeadlessChrome/60.0.3112.50 Safari/537.36":                                              {AgentHeadless, "HeadlessChrome", DeviceDesktop},
+		"Mozilla/5.0 (Unknown; Linux x86_64) AppleWebKit/534.34 (KHTML, like Gecko) PhantomJS/1.9.7 Safari/534.34":                                                      {AgentHeadless, "PhantomJS", DeviceDesktop},
+		"curl/7.35.0":                         {AgentLibrary, "curl", DeviceUnknown},
+		"Go-http-client/1.1":                  {AgentLibrary, "Go", DeviceUnknown},
+		"python-requests/2.3.0 CPython/2.7.6": {AgentLibrary, "Python", DeviceUnknown},
+		"Pingdom.com_bot_version_1.4":         {AgentBot, "", DeviceUnknown},
+		"SomeCrawler/1.0":                     {AgentBot, "", DeviceUnknown},
+		"Opera/9.80 (J2ME/MIDP; Opera Mini/9.80) Presto/2.5.25 Version/10.54": {AgentBrowser, "", DeviceMobile},
+		"-":              {AgentUnknown, "", DeviceUnknown},
+		"":               {AgentUnknown, "", DeviceUnknown},
+		"something else": {AgentUnknown, "", DeviceUnknown},
+	} {
+		got := ClassifyUserAgent(ua)
+		assert.Equal(want, got, ua)
+		assert.Equal(want.Kind != AgentBrowser && want.Kind != AgentUnknown, got.IsAutomated(), ua)
+	}
+}
+
+func TestLogRecord_Agent(t *testing.T) {
+	assert := assert.New(t)
+
+	var logs Logs
+	assert.Nil(json.Unmarshal(fetchJSON("logs.json"), &logs))
+	for _, r := range logs.Records {
+		assert.Equal(UserAgent{Kind: AgentBrowser, Device: DeviceDesktop}, r.Agent(), r.UserAgent)
+	}
+}
+
+func TestLoadUserAgentRules(t *testing.T) {
+	assert := assert.New(t)
+
+	c, err := LoadUserAgentRules(strings.NewReader(`{
+		"agents": [{"name": "Acme", "kind": "crawler", "pattern": "^acme"}],
+		"devices": [{"device": "tablet", "pattern": "android", "exclude": "mobile"}]
+	}`))
+	assert.Nil(err)
+	assert.Equal(UserAgent{AgentCrawler, "Acme", DeviceTablet}, c.Classify("ACME/1.0 (Android)"))
+	assert.Equal(UserAgent{AgentCrawler, "Acme", DeviceUnknown}, c.Classify("ACME/1.0 (Android; Mobile)"))
+	assert.Equal(UserAgent{AgentUnknown, "", DeviceUnknown}, c.Classify("curl/7.35.0"))
+
+	_, err = LoadUserAgentRules(strings.NewReader(`{"agents": [{"name": "bad", "pattern": "("}]}`))
+	assert.NotNil(err)
+	_, err = LoadUserAgentRules(strings.NewReader(`{"devices": [{"device": "tablet", "pattern": "x", "exclude": "("}]}`))
+	assert.NotNil(err)
+	_, err = LoadUserAgentRules(strings.NewReader(`{`))
+	assert.NotNil(err)
+}
+
+func BenchmarkClassifyUserAgent(b *testing.B) {
+	c, _ := NewUserAgentClassifier(DefaultUserAgentRules)
+	ua := "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_9_3) AppleWebKit/537.76.4 (KHTML, like Gecko) Version/7.0.4 Safari/537.76.4"
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		c.Classify(ua)
+	}
+}
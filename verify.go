@@ -0,0 +1,260 @@
+package maxcdn
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultCacheStatusHeaders are the response headers PurgeVerifier checks
+// for the edge cache status, in order.
+var DefaultCacheStatusHeaders = []string{"X-Cache", "X-Cache-Status", "X-Proxy-Cache"}
+
+// DefaultVerifyConcurrency is the number of files PurgeVerifier requests at
+// once when Concurrency is zero.
+const DefaultVerifyConcurrency = 8
+
+// CacheValidators holds the validators an edge returned for a URL, used to
+// tell whether the object changed after a purge.
+type CacheValidators struct {
+	ETag         string
+	LastModified string
+}
+
+// VerifyResult is the verification outcome for a single purged file.
+type VerifyResult struct {
+	File string
+	URL  string
+
+	// Verified is true once the edge answered with a cache MISS, or with
+	// different validators than were captured before the purge.
+	Verified    bool
+	CacheStatus string
+	CacheValidators
+	Attempts int
+
+	// Err holds the last request error, if any.
+	Err error
+}
+
+// PurgeVerifier checks that purged files were dropped by the edge by
+// requesting them through the zone's cdn_url.
+type PurgeVerifier struct {
+
+	// HTTPClient is used for edge requests, and will be set to
+	// http.DefaultClient by NewPurgeVerifier.
+	HTTPClient *http.Client
+
+	// Scheme used to build edge URLs, "http" by default.
+	Scheme string
+
+	// Attempts is the number of requests made per file before giving up.
+	Attempts int
+
+	// Backoff is the delay before the first retry. It doubles with every
+	// following retry.
+	Backoff time.Duration
+
+	// CacheStatusHeaders overrides DefaultCacheStatusHeaders.
+	CacheStatusHeaders []string
+
+	// Concurrency is the number of files requested at once,
+	// DefaultVerifyConcurrency when zero.
+	Concurrency int
+}
+
+// NewPurgeVerifier sets up a new PurgeVerifier with sensible defaults.
+func NewPurgeVerifier() *PurgeVerifier {
+	return &PurgeVerifier{
+		HTTPClient:  http.DefaultClient,
+		Scheme:      "http",
+		Attempts:    5,
+		Backoff:     time.Second,
+		Concurrency: DefaultVerifyConcurrency,
+	}
+}
+
+// Snapshot captures the current validators of files served by host, to be
+// passed to Verify after purging them. Files which can't be fetched are
+// left out.
+func (v *PurgeVerifier) Snapshot(host string, files []string) map[string]CacheValidators {
+	var (
+		mu       sync.Mutex
+		snapshot = map[string]CacheValidators{}
+	)
+
+	v.each(len(files), func(i int) {
+		res, err := v.fetch(v.url(host, files[i]))
+		if err != nil {
+			return
+		}
+
+		mu.Lock()
+		snapshot[files[i]] = validators(res)
+		mu.Unlock()
+	})
+	return snapshot
+}
+
+// Verify requests every file through host until the edge reports it as
+// purged, retrying with backoff. before may hold validators captured with
+// Snapshot, and may be nil. Results are returned in the order of files.
+func (v *PurgeVerifier) Verify(host string, files []string, before map[string]CacheValidators) []VerifyResult {
+	results := make([]VerifyResult, len(files))
+	v.each(len(files), func(i int) {
+		prev, ok := before[files[i]]
+		results[i] = v.verify(host, files[i], prev, ok)
+	})
+	return results
+}
+
+// each calls fn with every index below n from a pool of Concurrency
+// workers, and waits for them to finish.
+func (v *PurgeVerifier) each(n int, fn func(i int)) {
+	workers := v.Concurrency
+	if workers <= 0 {
+		workers = DefaultVerifyConcurrency
+	}
+	if workers > n {
+		workers = n
+	}
+
+	var (
+		wg      sync.WaitGroup
+		indexes = make(chan int)
+	)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
+
+// VerifyPurge looks up the zone's cdn_url and verifies files through it.
+func (max *MaxCDN) VerifyPurge(v *PurgeVerifier, zone int, files []string, before map[string]CacheValidators) ([]VerifyResult, error) {
+	host, err := max.CDNURL(zone)
+	if err != nil {
+		return nil, err
+	}
+	return v.Verify(host, files, before), nil
+}
+
+// CDNURL returns the cdn_url of a pull zone.
+func (max *MaxCDN) CDNURL(zone int) (string, error) {
+	var data struct {
+		PullZone struct {
+			CDNURL string `json:"cdn_url"`
+		} `json:"pullzone"`
+	}
+	if _, err := max.Get(&data, fmt.Sprintf("/zones/pull.json/%d", zone), nil); err != nil {
+		return "", err
+	}
+	if data.PullZone.CDNURL == "" {
+		return "", fmt.Errorf("maxcdn: zone %d has no cdn_url", zone)
+	}
+	return data.PullZone.CDNURL, nil
+}
+
+func (v *PurgeVerifier) verify(host, file string, before CacheValidators, known bool) VerifyResult {
+	result := VerifyResult{File: file, URL: v.url(host, file)}
+
+	attempts := v.Attempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	backoff := v.Backoff
+
+	for result.Attempts < attempts {
+		if result.Attempts > 0 {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+		result.Attempts++
+
+		res, err := v.fetch(result.URL)
+		result.Err = err
+		if err != nil {
+			continue
+		}
+
+		result.CacheStatus = v.cacheStatus(res)
+		result.CacheValidators = validators(res)
+
+		switch {
+		case strings.Contains(strings.ToUpper(result.CacheStatus), "MISS"):
+			result.Verified = true
+		case known && result.CacheValidators != (CacheValidators{}) && result.CacheValidators != before:
+			result.Verified = true
+		}
+		if result.Verified {
+			return result
+		}
+	}
+
+	if result.Err == nil {
+		result.Err = errors.New("maxcdn: edge still serving cached object")
+	}
+	return result
+}
+
+// fetch requests u, discarding the body.
+func (v *PurgeVerifier) fetch(u string) (http.Header, error) {
+	client := v.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	res, err := client.Get(u)
+	if err != nil {
+		return nil, err
+	}
+	_, _ = io.Copy(ioutil.Discard, res.Body)
+	_ = res.Body.Close()
+
+	if res.StatusCode > 399 {
+		return nil, fmt.Errorf("maxcdn: %s returned %s", u, res.Status)
+	}
+	return res.Header, nil
+}
+
+func (v *PurgeVerifier) cacheStatus(h http.Header) string {
+	headers := v.CacheStatusHeaders
+	if len(headers) == 0 {
+		headers = DefaultCacheStatusHeaders
+	}
+	for _, name := range headers {
+		if status := h.Get(name); status != "" {
+			return status
+		}
+	}
+	return ""
+}
+
+func (v *PurgeVerifier) url(host, file string) string {
+	scheme := v.Scheme
+	if scheme == "" {
+		scheme = "http"
+	}
+	return scheme + "://" + strings.TrimSuffix(host, "/") + normalizeFile(file)
+}
+
+func validators(h http.Header) CacheValidators {
+	return CacheValidators{
+		ETag:         h.Get("ETag"),
+		LastModified: h.Get("Last-Modified"),
+	}
+}
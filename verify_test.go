@@ -0,0 +1,172 @@
+package maxcdn
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// edgeStub is a fake edge which serves HIT for a number of requests per
+// path before switching to MISS.
+type edgeStub struct {
+	mu    sync.Mutex
+	hits  map[string]int
+	etags map[string]string
+}
+
+func (e *edgeStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if etag, ok := e.etags[r.URL.Path]; ok {
+		w.Header().Set("ETag", etag)
+	}
+	if r.URL.Path == "/missing.css" {
+		http.NotFound(w, r)
+		return
+	}
+
+	if e.hits[r.URL.Path] > 0 {
+		e.hits[r.URL.Path]--
+		w.Header().Set("X-Cache", "HIT")
+	} else {
+		w.Header().Set("X-Cache", "MISS")
+	}
+	w.Write([]byte("body"))
+}
+
+func testVerifier(client *http.Client) *PurgeVerifier {
+	v := NewPurgeVerifier()
+	v.HTTPClient = client
+	v.Attempts = 3
+	v.Backoff = 0
+	return v
+}
+
+func TestPurgeVerifier_Verify(t *testing.T) {
+	assert := assert.New(t)
+
+	edge := &edgeStub{
+		hits:  map[string]int{"/a.css": 0, "/b.css": 2, "/c.css": 5},
+		etags: map[string]string{},
+	}
+	server := httptest.NewServer(edge)
+	defer server.Close()
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	results := testVerifier(server.Client()).Verify(host, []string{"/a.css", "b.css", "/c.css", "/missing.css"}, nil)
+	assert.Len(results, 4)
+
+	assert.True(results[0].Verified)
+	assert.Equal(1, results[0].Attempts)
+	assert.Equal("MISS", results[0].CacheStatus)
+	assert.Equal(server.URL+"/a.css", results[0].URL)
+	assert.Nil(results[0].Err)
+
+	assert.True(results[1].Verified)
+	assert.Equal(3, results[1].Attempts)
+	assert.Equal("b.css", results[1].File)
+
+	assert.False(results[2].Verified)
+	assert.Equal(3, results[2].Attempts)
+	assert.Equal("HIT", results[2].CacheStatus)
+	assert.NotNil(results[2].Err)
+
+	assert.False(results[3].Verified)
+	assert.Contains(results[3].Err.Error(), "404")
+}
+
+func TestPurgeVerifier_Snapshot(t *testing.T) {
+	assert := assert.New(t)
+
+	edge := &edgeStub{
+		hits:  map[string]int{"/a.css": 10, "/b.css": 10},
+		etags: map[string]string{"/a.css": `"v1"`, "/b.css": `"v1"`},
+	}
+	server := httptest.NewServer(edge)
+	defer server.Close()
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	v := testVerifier(server.Client())
+
+	before := v.Snapshot(host, []string{"/a.css", "/b.css", "/missing.css"})
+	assert.Equal(map[string]CacheValidators{
+		"/a.css": {ETag: `"v1"`},
+		"/b.css": {ETag: `"v1"`},
+	}, before)
+
+	edge.mu.Lock()
+	edge.etags["/a.css"] = `"v2"`
+	edge.mu.Unlock()
+
+	results := v.Verify(host, []string{"/a.css", "/b.css"}, before)
+	assert.True(results[0].Verified)
+	assert.Equal(`"v2"`, results[0].ETag)
+	assert.False(results[1].Verified)
+}
+
+func TestPurgeVerifier_concurrency(t *testing.T) {
+	assert := assert.New(t)
+
+	var (
+		mu             sync.Mutex
+		inFlight, peak int
+		files          []string
+	)
+	client := stubHTTPFunc(func(r *http.Request) (*http.Response, error) {
+		mu.Lock()
+		if inFlight++; inFlight > peak {
+			peak = inFlight
+		}
+		mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+
+		rsp := stubBodyResponse(r, 200, "body")
+		rsp.Header = http.Header{"X-Cache": {"MISS"}}
+		return rsp, nil
+	})
+	for i := 0; i < 20; i++ {
+		files = append(files, fmt.Sprintf("/%d.css", i))
+	}
+
+	v := testVerifier(client)
+	v.Concurrency = 3
+	results := v.Verify("cdn.example.com", files, nil)
+	assert.Len(results, 20)
+	for i, result := range results {
+		assert.True(result.Verified)
+		assert.Equal(files[i], result.File)
+	}
+	assert.True(peak > 1 && peak <= 3, "peak %d", peak)
+
+	peak = 0
+	v.Concurrency = 0
+	assert.Len(v.Snapshot("cdn.example.com", files), 20)
+	assert.True(peak > 1 && peak <= DefaultVerifyConcurrency, "peak %d", peak)
+
+	assert.Empty(v.Verify("cdn.example.com", nil, nil))
+}
+
+func TestMaxCDN_CDNURL(t *testing.T) {
+	assert := assert.New(t)
+	max := NewMaxCDN("alias", "token", "secret")
+
+	var recorder http.Response
+	max.HTTPClient = stubHTTPOkRecorded(&recorder)
+
+	host, err := max.CDNURL(164197)
+	assert.Nil(err)
+	assert.Equal("cdn.example.net", host)
+	assert.Equal("/alias/zones/pull.json/164197", recorder.Request.URL.Path)
+}